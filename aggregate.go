@@ -1,43 +1,141 @@
 package morm
 
+// Aggregate 聚合函数，列没有指定表的时候在 FROM 的表中查找
 type Aggregate struct {
-	arg string
-	fn  string
+	arg   Column
+	fn    string
+	alias string
 }
 
 func (Aggregate) selectable() {}
 
+func (Aggregate) expr() {}
+
+// As 别名，只在 SELECT 部分生效，例如 Avg("Age").As("avg_age")
+func (a Aggregate) As(alias string) Aggregate {
+	return Aggregate{
+		arg:   a.arg,
+		fn:    a.fn,
+		alias: alias,
+	}
+}
+
+// Eq 用于 HAVING，例如 Count("Id").Eq(10)
+func (a Aggregate) Eq(arg any) Predicate {
+	return Predicate{
+		left:  a,
+		op:    opEQ,
+		right: valueOf(arg),
+	}
+}
+
+func (a Aggregate) NotEq(arg any) Predicate {
+	return Predicate{
+		left:  a,
+		op:    opNEQ,
+		right: valueOf(arg),
+	}
+}
+
+func (a Aggregate) Lt(arg any) Predicate {
+	return Predicate{
+		left:  a,
+		op:    opLT,
+		right: valueOf(arg),
+	}
+}
+
+func (a Aggregate) LtEq(arg any) Predicate {
+	return Predicate{
+		left:  a,
+		op:    opLTEQ,
+		right: valueOf(arg),
+	}
+}
+
+func (a Aggregate) Gt(arg any) Predicate {
+	return Predicate{
+		left:  a,
+		op:    opGT,
+		right: valueOf(arg),
+	}
+}
+
+func (a Aggregate) GtEq(arg any) Predicate {
+	return Predicate{
+		left:  a,
+		op:    opGTEQ,
+		right: valueOf(arg),
+	}
+}
+
 func Avg(col string) Aggregate {
 	return Aggregate{
-		arg: col,
+		arg: C(col),
 		fn:  "AVG",
 	}
 }
 
 func Min(col string) Aggregate {
 	return Aggregate{
-		arg: col,
+		arg: C(col),
 		fn:  "MIN",
 	}
 }
 
 func Max(col string) Aggregate {
 	return Aggregate{
-		arg: col,
+		arg: C(col),
 		fn:  "MAX",
 	}
 }
 
 func Count(col string) Aggregate {
 	return Aggregate{
-		arg: col,
+		arg: C(col),
 		fn:  "COUNT",
 	}
 }
 
 func Sum(col string) Aggregate {
 	return Aggregate{
-		arg: col,
+		arg: C(col),
+		fn:  "SUM",
+	}
+}
+
+// Avg 指定了表的列的聚合函数，例如 TableOf(&Order{}).C("Amount").Avg()
+func (c Column) Avg() Aggregate {
+	return Aggregate{
+		arg: c,
+		fn:  "AVG",
+	}
+}
+
+func (c Column) Min() Aggregate {
+	return Aggregate{
+		arg: c,
+		fn:  "MIN",
+	}
+}
+
+func (c Column) Max() Aggregate {
+	return Aggregate{
+		arg: c,
+		fn:  "MAX",
+	}
+}
+
+func (c Column) Count() Aggregate {
+	return Aggregate{
+		arg: c,
+		fn:  "COUNT",
+	}
+}
+
+func (c Column) Sum() Aggregate {
+	return Aggregate{
+		arg: c,
 		fn:  "SUM",
 	}
 }
@@ -0,0 +1,127 @@
+package morm
+
+import (
+	"context"
+	"database/sql"
+)
+
+// BatchSize 每 n 行执行一条 INSERT 语句，和 AutoBatch 同时使用时取较小的行数
+func (i *Inserter[T]) BatchSize(n int) *Inserter[T] {
+	i.batchSize = n
+	return i
+}
+
+// AutoBatch 按照方言的占位符上限自动分批，例如 MySQL 的 65535
+func (i *Inserter[T]) AutoBatch() *Inserter[T] {
+	i.autoBatch = true
+	return i
+}
+
+// BatchInTx 分批执行的时候在同一个事务中执行，任意一批失败都会回滚
+// 已经在事务中的时候直接使用当前的事务
+func (i *Inserter[T]) BatchInTx() *Inserter[T] {
+	i.batchInTx = true
+	return i
+}
+
+// execBatch 按顺序执行每一批，返回的 RowsAffected 是所有批次的总和，LastInsertId 是第一批的结果
+// 执行失败的时候没有使用事务，RowsAffected 同时返回已经执行的批次的总和和错误
+func (i *Inserter[T]) execBatch(ctx context.Context) Result {
+	rows, err := i.batchRows()
+	if err != nil {
+		return Result{err: err}
+	}
+	if len(i.values) <= rows {
+		return i.exec(ctx)
+	}
+
+	sess := i.sess
+	// tx 不为 nil 表示事务是这里开启的，需要在这里提交
+	var tx *Tx
+	if db, ok := sess.(*DB); ok && i.batchInTx {
+		tx, err = db.BeginTx(ctx, nil)
+		if err != nil {
+			return Result{err: err}
+		}
+		defer func() { _ = tx.RollbackIfNotCommit() }()
+		sess = tx
+	}
+
+	res := &batchResult{}
+	for start := 0; start < len(i.values); start += rows {
+		end := start + rows
+		if end > len(i.values) {
+			end = len(i.values)
+		}
+		chunkRes := i.chunk(sess, i.values[start:end]).exec(ctx)
+		affected, err := chunkRes.RowsAffected()
+		if res.Result == nil {
+			res.Result = chunkRes.res
+		}
+		res.rowsAffected += affected
+		if err != nil {
+			// 使用事务的时候返回之后回滚，没有插入任何数据
+			if tx != nil || res.Result == nil {
+				return Result{err: err}
+			}
+			return Result{res: res, err: err}
+		}
+	}
+	if tx != nil {
+		if err = tx.Commit(); err != nil {
+			return Result{err: err}
+		}
+	}
+	return Result{res: res}
+}
+
+// batchRows 每一批的行数，自动分批的时候先构造只有一行的语句计算参数个数
+func (i *Inserter[T]) batchRows() (int, error) {
+	rows := i.batchSize
+	if !i.autoBatch || len(i.values) == 0 {
+		return rows, nil
+	}
+	chunk := i.chunk(i.sess, i.values[:1])
+	q, err := chunk.Build()
+	if err != nil {
+		return 0, err
+	}
+	// 除了 VALUES 之外还有 Upsert 的参数
+	fixedArgs := len(q.Args) - chunk.rowArgs
+	autoRows := 1
+	if chunk.rowArgs > 0 {
+		autoRows = (i.dialect.MaxPlaceholders() - fixedArgs) / chunk.rowArgs
+	}
+	if autoRows < 1 {
+		autoRows = 1
+	}
+	if rows <= 0 || autoRows < rows {
+		rows = autoRows
+	}
+	return rows, nil
+}
+
+// chunk 复制除了 values 之外的设置，构造器只能 Build 一次，所以每一批都需要新的 Inserter
+func (i *Inserter[T]) chunk(sess Session, values []*T) *Inserter[T] {
+	return &Inserter[T]{
+		builder: builder{
+			core: sess.getCore(),
+		},
+		sess:        sess,
+		values:      values,
+		columns:     i.columns,
+		onDuplicate: i.onDuplicate,
+		returning:   i.returning,
+		fillID:      i.fillID,
+	}
+}
+
+// batchResult 分批执行的结果，LastInsertId 是第一批的结果，和 MySQL 一条语句插入多行的时候一样是第一行的 id
+type batchResult struct {
+	sql.Result
+	rowsAffected int64
+}
+
+func (r *batchResult) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
@@ -0,0 +1,256 @@
+package morm
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// smallDialect 占位符上限很小的方言，用来验证自动分批
+type smallDialect struct {
+	mysqlDialect
+}
+
+func (dialect *smallDialect) MaxPlaceholders() int {
+	return 4
+}
+
+func tagModels(names ...string) []*TagModel {
+	res := make([]*TagModel, 0, len(names))
+	for _, name := range names {
+		res = append(res, &TagModel{Name: name})
+	}
+	return res
+}
+
+func TestInserter_Batch(t *testing.T) {
+	tests := []struct {
+		name         string
+		mock         func(mock sqlmock.Sqlmock)
+		i            func(db *DB) *Inserter[TagModel]
+		wantAffected int64
+		wantId       int64
+		wantErr      error
+	}{
+		{
+			name: "batch size",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `tag_model`\\(`name`\\) VALUES\\(\\?\\),\\(\\?\\);").
+					WithArgs("a", "b").WillReturnResult(sqlmock.NewResult(1, 2))
+				mock.ExpectExec("INSERT INTO `tag_model`\\(`name`\\) VALUES\\(\\?\\),\\(\\?\\);").
+					WithArgs("c", "d").WillReturnResult(sqlmock.NewResult(3, 2))
+				mock.ExpectExec("INSERT INTO `tag_model`\\(`name`\\) VALUES\\(\\?\\);").
+					WithArgs("e").WillReturnResult(sqlmock.NewResult(5, 1))
+			},
+			i: func(db *DB) *Inserter[TagModel] {
+				return NewInserter[TagModel](db).BatchSize(2).Values(tagModels("a", "b", "c", "d", "e")...)
+			},
+			wantAffected: 5,
+			// 和一条语句插入多行一样，是第一批的 LastInsertId
+			wantId: 1,
+		},
+		{
+			name: "single batch",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `tag_model`\\(`name`\\) VALUES\\(\\?\\),\\(\\?\\);").
+					WithArgs("a", "b").WillReturnResult(sqlmock.NewResult(1, 2))
+			},
+			i: func(db *DB) *Inserter[TagModel] {
+				return NewInserter[TagModel](db).BatchSize(2).BatchInTx().Values(tagModels("a", "b")...)
+			},
+			wantAffected: 2,
+		},
+		{
+			name: "auto batch",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `tag_model`\\(`name`\\) VALUES\\(\\?\\),\\(\\?\\),\\(\\?\\),\\(\\?\\);").
+					WithArgs("a", "b", "c", "d").WillReturnResult(sqlmock.NewResult(1, 4))
+				mock.ExpectExec("INSERT INTO `tag_model`\\(`name`\\) VALUES\\(\\?\\);").
+					WithArgs("e").WillReturnResult(sqlmock.NewResult(5, 1))
+			},
+			i: func(db *DB) *Inserter[TagModel] {
+				return NewInserter[TagModel](db).AutoBatch().Values(tagModels("a", "b", "c", "d", "e")...)
+			},
+			wantAffected: 5,
+			wantId:       1,
+		},
+		{
+			name: "auto batch smaller than batch size",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `tag_model`\\(`name`\\) VALUES\\(\\?\\),\\(\\?\\),\\(\\?\\),\\(\\?\\);").
+					WithArgs("a", "b", "c", "d").WillReturnResult(sqlmock.NewResult(1, 4))
+				mock.ExpectExec("INSERT INTO `tag_model`\\(`name`\\) VALUES\\(\\?\\);").
+					WithArgs("e").WillReturnResult(sqlmock.NewResult(5, 1))
+			},
+			i: func(db *DB) *Inserter[TagModel] {
+				return NewInserter[TagModel](db).BatchSize(10).AutoBatch().Values(tagModels("a", "b", "c", "d", "e")...)
+			},
+			wantAffected: 5,
+		},
+		{
+			// Upsert 占用了一个占位符，每一批只能插入三行
+			name: "auto batch upsert",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `tag_model`\\(`name`\\) VALUES\\(\\?\\),\\(\\?\\),\\(\\?\\) "+
+					"ON DUPLICATE KEY UPDATE `name`=\\?;").
+					WithArgs("a", "b", "c", "x").WillReturnResult(sqlmock.NewResult(1, 3))
+				mock.ExpectExec("INSERT INTO `tag_model`\\(`name`\\) VALUES\\(\\?\\),\\(\\?\\) "+
+					"ON DUPLICATE KEY UPDATE `name`=\\?;").
+					WithArgs("d", "e", "x").WillReturnResult(sqlmock.NewResult(4, 2))
+			},
+			i: func(db *DB) *Inserter[TagModel] {
+				return NewInserter[TagModel](db).AutoBatch().Values(tagModels("a", "b", "c", "d", "e")...).
+					Upsert().Update(Assign("Name", "x"))
+			},
+			wantAffected: 5,
+		},
+		{
+			name: "in tx",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `tag_model`").WithArgs("a", "b").
+					WillReturnResult(sqlmock.NewResult(1, 2))
+				mock.ExpectExec("INSERT INTO `tag_model`").WithArgs("c").
+					WillReturnResult(sqlmock.NewResult(3, 1))
+				mock.ExpectCommit()
+			},
+			i: func(db *DB) *Inserter[TagModel] {
+				return NewInserter[TagModel](db).BatchSize(2).BatchInTx().Values(tagModels("a", "b", "c")...)
+			},
+			wantAffected: 3,
+		},
+		{
+			name: "in tx rollback",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `tag_model`").WithArgs("a", "b").
+					WillReturnResult(sqlmock.NewResult(1, 2))
+				mock.ExpectExec("INSERT INTO `tag_model`").WithArgs("c").
+					WillReturnError(errors.New("exec error"))
+				mock.ExpectRollback()
+			},
+			i: func(db *DB) *Inserter[TagModel] {
+				return NewInserter[TagModel](db).BatchSize(2).BatchInTx().Values(tagModels("a", "b", "c")...)
+			},
+			wantErr: errors.New("exec error"),
+		},
+		{
+			name: "exec error",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `tag_model`").WithArgs("a", "b").
+					WillReturnError(errors.New("exec error"))
+			},
+			i: func(db *DB) *Inserter[TagModel] {
+				return NewInserter[TagModel](db).BatchSize(2).Values(tagModels("a", "b", "c")...)
+			},
+			wantErr: errors.New("exec error"),
+		},
+		{
+			// 没有使用事务，同时返回已经执行的批次的行数
+			name: "partial exec error",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `tag_model`").WithArgs("a", "b").
+					WillReturnResult(sqlmock.NewResult(1, 2))
+				mock.ExpectExec("INSERT INTO `tag_model`").WithArgs("c", "d").
+					WillReturnError(errors.New("exec error"))
+			},
+			i: func(db *DB) *Inserter[TagModel] {
+				return NewInserter[TagModel](db).BatchSize(2).Values(tagModels("a", "b", "c", "d", "e")...)
+			},
+			wantAffected: 2,
+			wantId:       1,
+			wantErr:      errors.New("exec error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer func() { _ = mockDB.Close() }()
+			tt.mock(mock)
+
+			db, err := OpenDB(mockDB, DBWithDialect(&smallDialect{}))
+			require.NoError(t, err)
+			i := tt.i(db)
+			res := i.Exec(context.Background())
+			affected, err := res.RowsAffected()
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantAffected, affected)
+			assert.NoError(t, mock.ExpectationsWereMet())
+			if tt.wantId > 0 {
+				id, _ := res.LastInsertId()
+				assert.Equal(t, tt.wantId, id)
+			}
+		})
+	}
+}
+
+func TestInserter_BatchFillAutoIncrementID(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+	mock.ExpectExec("INSERT INTO `tag_model`").WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectExec("INSERT INTO `tag_model`").WillReturnResult(sqlmock.NewResult(10, 1))
+
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+	// 每一批按照自己的 LastInsertId 回填
+	vals := tagModels("a", "b", "c")
+	affected, err := NewInserter[TagModel](db).BatchSize(2).FillAutoIncrementID().
+		Values(vals...).Exec(context.Background()).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), affected)
+	ids := make([]int64, 0, len(vals))
+	for _, val := range vals {
+		ids = append(ids, val.Id)
+	}
+	assert.Equal(t, []int64{1, 2, 10}, ids)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInserter_BatchExistingTx(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `tag_model`").WithArgs("a", "b").WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectExec("INSERT INTO `tag_model`").WithArgs("c").WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectCommit()
+
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+	// 已经在事务中的时候使用当前的事务，由调用方提交
+	err = db.DoTx(context.Background(), func(ctx context.Context, tx *Tx) error {
+		_, err := NewInserter[TagModel](tx).BatchSize(2).BatchInTx().
+			Values(tagModels("a", "b", "c")...).Exec(ctx).RowsAffected()
+		return err
+	}, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInserter_BatchSQLite(t *testing.T) {
+	db, err := Open("sqlite3", "file:batch.db?cache=shared&mode=memory", DBWithDialect(DialectSQLite))
+	require.NoError(t, err)
+	ctx := context.Background()
+	_, err = db.db.ExecContext(ctx, "DROP TABLE IF EXISTS `tag_model`")
+	require.NoError(t, err)
+	_, err = db.db.ExecContext(ctx, "CREATE TABLE `tag_model`("+
+		"`id` INTEGER PRIMARY KEY AUTOINCREMENT, `name` TEXT, `version` INTEGER DEFAULT 0)")
+	require.NoError(t, err)
+
+	// 超过 SQLite 的占位符上限
+	vals := make([]*TagModel, 0, 40000)
+	for j := 0; j < 40000; j++ {
+		vals = append(vals, &TagModel{Name: "xiao"})
+	}
+	_, err = NewInserter[TagModel](db).Values(vals...).Exec(ctx).RowsAffected()
+	assert.Error(t, err)
+
+	affected, err := NewInserter[TagModel](db).AutoBatch().BatchInTx().Values(vals...).Exec(ctx).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(40000), affected)
+}
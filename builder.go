@@ -1,19 +1,315 @@
 package morm
 
 import (
+	"github.com/soluble1/morm/internal/errs"
 	"github.com/soluble1/morm/model"
 	"strings"
 )
 
 type builder struct {
-	model   *model.Model
-	sb      strings.Builder
-	args    []any
-	dialect Dialect
+	core
+	model *model.Model
+	sb    strings.Builder
+	args  []any
+	// argBase 作为子查询的时候外层已经有的参数个数，占位符的序号从 argBase + 1 开始
+	argBase int
+	// from SELECT 的 FROM 部分，没有指定表的列在这里查找，Join 的时候左右两边的表都会查找
+	from TableReference
 }
 
 func (b *builder) quote(name string) {
-	b.sb.WriteByte(b.dialect.quoter())
-	b.sb.WriteString(name)
-	b.sb.WriteByte(b.dialect.quoter())
+	b.sb.WriteString(b.dialect.Quote(name))
+}
+
+// quoteTable 写入模型的表名，设置了 schema 的时候带上 schema
+func (b *builder) quoteTable(m *model.Model) {
+	if m.Schema != "" {
+		b.quote(m.Schema)
+		b.sb.WriteByte('.')
+	}
+	b.quote(m.TableName)
+}
+
+// addArg 写入占位符并记录参数，所有语句的参数都通过这里添加
+func (b *builder) addArg(val any) {
+	b.args = append(b.args, val)
+	b.sb.WriteString(b.dialect.Placeholder(b.argBase + len(b.args)))
+}
+
+func (b *builder) setArgBase(base int) {
+	b.argBase = base
+}
+
+// buildRaw Raw 中的 ? 按照顺序替换为方言的占位符，多出来的参数直接追加
+func (b *builder) buildRaw(raw RawExpr) {
+	args := raw.args
+	for _, c := range raw.raw {
+		if c == '?' && len(args) > 0 {
+			b.addArg(args[0])
+			args = args[1:]
+			continue
+		}
+		b.sb.WriteRune(c)
+	}
+	b.args = append(b.args, args...)
+}
+
+// writer 给 Dialect 使用的 SQLBuilder
+func (b *builder) writer() SQLBuilder {
+	return sqlWriter{b: b}
+}
+
+// buildColumn 如果列指定了表，会用表的别名或者表名限定列
+func (b *builder) buildColumn(c Column) error {
+	switch table := c.table.(type) {
+	case nil:
+	case Table:
+		if table.alias != "" {
+			b.quote(table.alias)
+		} else {
+			m, err := b.r.Get(table.entity)
+			if err != nil {
+				return err
+			}
+			b.quoteTable(m)
+		}
+		b.sb.WriteByte('.')
+	case Subquery:
+		b.quote(table.alias)
+		b.sb.WriteByte('.')
+	default:
+		return errs.NewErrUnsupportedTable(table)
+	}
+	colName, err := b.colName(c.table, c.name)
+	if err != nil {
+		return err
+	}
+	b.quote(colName)
+	return nil
+}
+
+// colName 在 table 中找到字段对应的列名，Join 会先找左边再找右边
+func (b *builder) colName(table TableReference, field string) (string, error) {
+	switch tab := table.(type) {
+	case nil:
+		fd, ok := b.model.FieldMap[field]
+		if !ok {
+			return "", errs.NewErrUnKnowField(field)
+		}
+		return fd.ColName, nil
+	case Table:
+		m, err := b.r.Get(tab.entity)
+		if err != nil {
+			return "", err
+		}
+		fd, ok := m.FieldMap[field]
+		if !ok {
+			return "", errs.NewErrUnKnowField(field)
+		}
+		return fd.ColName, nil
+	case Join:
+		colName, err := b.colName(tab.left, field)
+		if err != nil {
+			return b.colName(tab.right, field)
+		}
+		return colName, nil
+	case Subquery:
+		// 子查询指定了列，那么只能使用这些列或者它们的别名
+		if len(tab.columns) > 0 {
+			for _, c := range tab.columns {
+				switch col := c.(type) {
+				case Column:
+					if col.alias == field {
+						return field, nil
+					}
+					if col.alias == "" && col.name == field {
+						if col.table != nil {
+							return b.colName(col.table, field)
+						}
+						return b.colName(tab.table, field)
+					}
+				case Aggregate:
+					if col.alias == field {
+						return field, nil
+					}
+				}
+			}
+			return "", errs.NewErrUnKnowField(field)
+		}
+		return b.colName(tab.table, field)
+	default:
+		return "", errs.NewErrUnKnowField(field)
+	}
+}
+
+// buildSubquery 子查询的参数按照出现的顺序合并到外层的参数中
+func (b *builder) buildSubquery(sub Subquery, useAlias bool) error {
+	// 子查询的占位符接着外层的序号，构造之后恢复，同一个子查询可能在别的位置再次使用
+	if s, ok := sub.s.(interface{ setArgBase(base int) }); ok {
+		s.setArgBase(b.argBase + len(b.args))
+		defer s.setArgBase(0)
+	}
+	q, err := sub.s.Build()
+	if err != nil {
+		return err
+	}
+	b.sb.WriteByte('(')
+	// 去掉子查询末尾的分号
+	b.sb.WriteString(q.SQL[:len(q.SQL)-1])
+	b.sb.WriteByte(')')
+	if len(q.Args) > 0 {
+		b.args = append(b.args, q.Args...)
+	}
+	if useAlias && sub.alias != "" {
+		b.sb.WriteString(" AS ")
+		b.quote(sub.alias)
+	}
+	return nil
+}
+
+func (b *builder) buildAs(alias string) {
+	if alias != "" {
+		b.sb.WriteString(" AS ")
+		b.quote(alias)
+	}
+}
+
+// buildPredicates 多个 Predicate 之间用 AND 连接
+func (b *builder) buildPredicates(ps []Predicate) error {
+	pred := ps[0]
+	for i := 1; i < len(ps); i++ {
+		pred = pred.And(ps[i])
+	}
+	return b.buildExpression(pred)
+}
+
+func (b *builder) buildAggregate(a Aggregate) error {
+	b.sb.WriteString(a.fn)
+	b.sb.WriteByte('(')
+	if err := b.buildFromColumn(a.arg); err != nil {
+		return err
+	}
+	b.sb.WriteByte(')')
+	return nil
+}
+
+// buildFromColumn 没有指定表的列在 FROM 的表中查找，例如 Join 右边的表的列，指定了表的列和 buildColumn 一样
+// SELECT、WHERE、GROUP BY、HAVING 和 ORDER BY 中的列都通过这里构造，同一个列只有一种解析方式
+func (b *builder) buildFromColumn(c Column) error {
+	if c.table != nil || b.from == nil {
+		return b.buildColumn(c)
+	}
+	colName, err := b.colName(b.from, c.name)
+	if err != nil {
+		return err
+	}
+	b.quote(colName)
+	return nil
+}
+
+func (b *builder) buildExpression(expression Expression) error {
+	switch expr := expression.(type) {
+	case nil:
+		return nil
+	case Value:
+		b.addArg(expr.val)
+	case Column:
+		return b.buildFromColumn(expr)
+	case Aggregate:
+		return b.buildAggregate(expr)
+	case Predicate:
+		P, ok := expr.left.(Predicate)
+		if ok && P.op != opNOT {
+			b.sb.WriteByte('(')
+		}
+		if err := b.buildExpression(expr.left); err != nil {
+			return err
+		}
+		if ok && P.op != opNOT {
+			b.sb.WriteByte(')')
+		}
+
+		if expr.left != nil && expr.op != "" {
+			b.sb.WriteByte(' ')
+		}
+		b.sb.WriteString(expr.op.String())
+		if expr.op != "" && expr.right != nil {
+			b.sb.WriteByte(' ')
+		}
+
+		_, ok = expr.right.(Predicate)
+		if ok {
+			b.sb.WriteByte('(')
+		}
+		if err := b.buildExpression(expr.right); err != nil {
+			return err
+		}
+		if ok {
+			b.sb.WriteByte(')')
+		}
+	case Subquery:
+		return b.buildSubquery(expr, false)
+	case SubqueryExpr:
+		b.sb.WriteString(expr.pred)
+		b.sb.WriteByte(' ')
+		return b.buildSubquery(expr.s, false)
+	case valueList:
+		b.sb.WriteByte('(')
+		for i, val := range expr.vals {
+			if i > 0 {
+				b.sb.WriteByte(',')
+			}
+			// 和其它操作符一样，值也可以是 Column、MathExpr 之类的表达式
+			if err := b.buildExpression(valueOf(val)); err != nil {
+				return err
+			}
+		}
+		b.sb.WriteByte(')')
+	case valueRange:
+		if err := b.buildExpression(expr.start); err != nil {
+			return err
+		}
+		b.sb.WriteString(" AND ")
+		return b.buildExpression(expr.end)
+	case MathExpr:
+		if err := b.buildMathOperand(expr.left); err != nil {
+			return err
+		}
+		b.sb.WriteByte(' ')
+		b.sb.WriteString(expr.op.String())
+		b.sb.WriteByte(' ')
+		return b.buildMathOperand(expr.right)
+	case FuncExpr:
+		b.sb.WriteString(expr.name)
+		b.sb.WriteByte('(')
+		for i, arg := range expr.args {
+			if i > 0 {
+				b.sb.WriteByte(',')
+			}
+			if err := b.buildExpression(arg); err != nil {
+				return err
+			}
+		}
+		b.sb.WriteByte(')')
+	case RawExpr:
+		b.buildRaw(expr)
+	default:
+		return errs.NewErrUnsupportedExpression(expression)
+	}
+	return nil
+}
+
+// buildMathOperand 嵌套的算术表达式需要加上括号
+func (b *builder) buildMathOperand(expr Expression) error {
+	_, ok := expr.(MathExpr)
+	if ok {
+		b.sb.WriteByte('(')
+	}
+	if err := b.buildExpression(expr); err != nil {
+		return err
+	}
+	if ok {
+		b.sb.WriteByte(')')
+	}
+	return nil
 }
@@ -0,0 +1,146 @@
+package morm
+
+import (
+	"github.com/soluble1/morm/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// doubleQuoteDialect 用来验证所有语句都使用了方言的引号
+type doubleQuoteDialect struct {
+	mysqlDialect
+}
+
+func (dialect *doubleQuoteDialect) Quote(name string) string {
+	return dialect.StandardSQL.Quote(name)
+}
+
+func TestBuilder_Dialect(t *testing.T) {
+	db, err := OpenDB(memoryDB(t).db, DBWithDialect(&doubleQuoteDialect{}))
+	require.NoError(t, err)
+	tests := []struct {
+		name      string
+		b         QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "select",
+			b:    NewSelector[TestModel](db).Where(C("Id").Eq(1), C("Age").In(18, 19)),
+			wantQuery: &Query{
+				SQL:  `SELECT * FROM "test_model" WHERE ("id" = ?) AND ("age" IN (?,?));`,
+				Args: []any{1, 18, 19},
+			},
+		},
+		{
+			name: "update",
+			b: NewUpdater[TestModel](db).Set(C("Age").Eq(C("Age").Add(1))).
+				Where(C("Id").Eq(1).Or(C("FirstName").Like("xiao%"))),
+			wantQuery: &Query{
+				SQL:  `UPDATE "test_model" SET "age" = "age" + ? WHERE ("id" = ?) OR ("first_name" LIKE ?);`,
+				Args: []any{1, 1, "xiao%"},
+			},
+		},
+		{
+			name: "delete",
+			b:    NewDeleter[TestModel](db).Where(C("Id").Eq(1), Not(C("Age").Gt(18))),
+			wantQuery: &Query{
+				SQL:  `DELETE FROM "test_model" WHERE ("id" = ?) AND (NOT ("age" > ?));`,
+				Args: []any{1, 18},
+			},
+		},
+		{
+			name: "delete subquery",
+			b: func() QueryBuilder {
+				sub := NewSelector[TestModel](db).Select(C("Id")).Where(C("Age").Lt(18)).AsSubquery("sub")
+				return NewDeleter[TestModel](db).Where(C("Id").InQuery(sub))
+			}(),
+			wantQuery: &Query{
+				SQL:  `DELETE FROM "test_model" WHERE "id" IN (SELECT "id" FROM "test_model" WHERE "age" < ?);`,
+				Args: []any{18},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := tt.b.Build()
+			assert.Equal(t, tt.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.wantQuery, q)
+		})
+	}
+}
+
+func TestBuilder_Schema(t *testing.T) {
+	r := model.NewRegistry()
+	_, err := r.Register(&TestModel{}, model.ModelWithSchema("analytics"),
+		model.ModelWithColumnName("FirstName", "first_name_t"))
+	require.NoError(t, err)
+	db, err := OpenDB(memoryDB(t).db, DBWithRegistry(r))
+	require.NoError(t, err)
+	tests := []struct {
+		name      string
+		b         QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "select",
+			b:    NewSelector[TestModel](db).Select(C("FirstName")).Where(C("Id").Eq(1)),
+			wantQuery: &Query{
+				SQL:  "SELECT `first_name_t` FROM `analytics`.`test_model` WHERE `id` = ?;",
+				Args: []any{1},
+			},
+		},
+		{
+			name: "join",
+			b: func() QueryBuilder {
+				t1 := TableOf(&TestModel{})
+				t2 := TableOf(&TagModel{}).As("t2")
+				return NewSelector[TestModel](db).Select(t1.C("FirstName")).
+					From(t1.Join(t2).On(t1.C("Id").Eq(t2.C("Id"))))
+			}(),
+			wantQuery: &Query{
+				SQL: "SELECT `analytics`.`test_model`.`first_name_t` FROM " +
+					"(`analytics`.`test_model` JOIN `tag_model` AS `t2` ON `analytics`.`test_model`.`id` = `t2`.`id`);",
+			},
+		},
+		{
+			name: "insert",
+			b:    NewInserter[TestModel](db).Values(&TestModel{Id: 1, FirstName: "xiao"}).Columns("Id", "FirstName"),
+			wantQuery: &Query{
+				SQL:  "INSERT INTO `analytics`.`test_model`(`id`,`first_name_t`) VALUES(?,?);",
+				Args: []any{int64(1), "xiao"},
+			},
+		},
+		{
+			name: "update",
+			b:    NewUpdater[TestModel](db).Set(C("FirstName").Eq("xiao")).Where(C("Id").Eq(1)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `analytics`.`test_model` SET `first_name_t` = ? WHERE `id` = ?;",
+				Args: []any{"xiao", 1},
+			},
+		},
+		{
+			name: "delete",
+			b:    NewDeleter[TestModel](db).Where(C("Id").Eq(1)),
+			wantQuery: &Query{
+				SQL:  "DELETE FROM `analytics`.`test_model` WHERE `id` = ?;",
+				Args: []any{1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := tt.b.Build()
+			assert.Equal(t, tt.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.wantQuery, q)
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package morm
+
+import (
+	"github.com/soluble1/morm/internal/valuer"
+	"github.com/soluble1/morm/model"
+)
+
+// core DB 和 Tx 共享的部分
+type core struct {
+	r          model.Registry
+	dialect    Dialect
+	valCreator valuer.Creator
+	mdls       []Middleware
+}
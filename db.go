@@ -1,20 +1,20 @@
 package morm
 
 import (
+	"context"
 	"database/sql"
+	"github.com/soluble1/morm/internal/errs"
 	"github.com/soluble1/morm/internal/valuer"
 	"github.com/soluble1/morm/model"
 )
 
+var _ Session = &DB{}
+
 type DBOption func(db *DB)
 
 type DB struct {
+	core
 	db *sql.DB
-	r  model.Registry
-
-	valCreator valuer.Creator
-
-	dialect Dialect
 }
 
 func DBWithRegistry(r model.Registry) DBOption {
@@ -34,10 +34,12 @@ func Open(driver string, dsn string, opts ...DBOption) (*DB, error) {
 
 func OpenDB(db *sql.DB, opts ...DBOption) (*DB, error) {
 	res := &DB{
-		r:          model.NewRegistry(),
-		db:         db,
-		valCreator: valuer.NewUnsafeValue,
-		dialect:    &mysqlDialect{},
+		core: core{
+			r:          model.NewRegistry(),
+			valCreator: valuer.NewUnsafeValue,
+			dialect:    DialectMySQL,
+		},
+		db: db,
 	}
 
 	for _, opt := range opts {
@@ -58,3 +60,53 @@ func DBWithDialect(dialect Dialect) DBOption {
 		db.dialect = dialect
 	}
 }
+
+func (db *DB) getCore() core {
+	return db.core
+}
+
+func (db *DB) queryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return db.db.QueryContext(ctx, query, args...)
+}
+
+func (db *DB) execContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return db.db.ExecContext(ctx, query, args...)
+}
+
+// BeginTx 开启一个事务
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	tx, err := db.db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{
+		tx: tx,
+		db: db,
+	}, nil
+}
+
+// DoTx 在事务中执行 fn，fn 返回 error 或者 panic 时回滚，否则提交
+func (db *DB) DoTx(ctx context.Context,
+	fn func(ctx context.Context, tx *Tx) error,
+	opts *sql.TxOptions) (err error) {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	panicked := true
+	defer func() {
+		if panicked || err != nil {
+			e := tx.Rollback()
+			if e != nil {
+				err = errs.NewErrFailToRollbackTx(err, e, panicked)
+			}
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	err = fn(ctx, tx)
+	panicked = false
+	return err
+}
@@ -0,0 +1,223 @@
+package morm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestDB_DoTx(t *testing.T) {
+	bizErr := errors.New("biz error")
+	tests := []struct {
+		name    string
+		mock    func(mock sqlmock.Sqlmock)
+		fn      func(ctx context.Context, tx *Tx) error
+		wantErr error
+	}{
+		{
+			name: "commit",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `test_model` .*").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			fn: func(ctx context.Context, tx *Tx) error {
+				_, err := NewUpdater[TestModel](tx).Set(C("Age").Eq(19)).
+					Where(C("Id").Eq(12)).Exec(ctx).RowsAffected()
+				return err
+			},
+		},
+		{
+			name: "rollback",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+			},
+			fn: func(ctx context.Context, tx *Tx) error {
+				return bizErr
+			},
+			wantErr: bizErr,
+		},
+		{
+			name: "begin error",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin().WillReturnError(bizErr)
+			},
+			fn: func(ctx context.Context, tx *Tx) error {
+				return nil
+			},
+			wantErr: bizErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer func() { _ = mockDB.Close() }()
+			tt.mock(mock)
+
+			db, err := OpenDB(mockDB)
+			require.NoError(t, err)
+			err = db.DoTx(context.Background(), tt.fn, nil)
+			assert.Equal(t, tt.wantErr, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDB_DoTxPanic(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+	assert.Panics(t, func() {
+		_ = db.DoTx(context.Background(), func(ctx context.Context, tx *Tx) error {
+			panic("biz panic")
+		}, nil)
+	})
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_FieldTypes(t *testing.T) {
+	for _, opt := range []DBOption{DBUseReflectValuer(), func(db *DB) {}} {
+		db, err := Open("sqlite3", "file:field_types.db?cache=shared&mode=memory", opt)
+		require.NoError(t, err)
+		ctx := context.Background()
+		_, err = db.db.ExecContext(ctx, "DROP TABLE IF EXISTS `field_type_model`")
+		require.NoError(t, err)
+		_, err = db.db.ExecContext(ctx, "CREATE TABLE `field_type_model`("+
+			"`id` INTEGER PRIMARY KEY, `name` TEXT, `age` INTEGER, `nick` TEXT, "+
+			"`score` INTEGER, `balance` INTEGER, `create_time` DATETIME)")
+		require.NoError(t, err)
+
+		name, age := "xiao", int64(18)
+		createTime := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+		full := &FieldTypeModel{
+			Id:         1,
+			Name:       &name,
+			Age:        &age,
+			Nick:       sql.NullString{Valid: true, String: "long"},
+			Score:      sql.NullInt64{Valid: true, Int64: 99},
+			Balance:    Amount{Yuan: 12, Fen: 34},
+			CreateTime: createTime,
+		}
+		// 指针和 NullXxx 都是 NULL
+		empty := &FieldTypeModel{Id: 2, CreateTime: createTime}
+		affected, err := NewInserter[FieldTypeModel](db).Values(full, empty).Exec(ctx).RowsAffected()
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), affected)
+
+		res, err := NewSelector[FieldTypeModel](db).OrderBy(Asc("Id")).GetMulti(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []*FieldTypeModel{full, empty}, res)
+
+		// driver.Valuer 作为参数
+		affected, err = NewUpdater[FieldTypeModel](db).Set(C("Balance").Eq(&Amount{Yuan: 1}), C("Name").Eq(&name)).
+			Where(C("Balance").Eq(&Amount{Yuan: 12, Fen: 34})).Exec(ctx).RowsAffected()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), affected)
+		got, err := NewSelector[FieldTypeModel](db).Where(C("Balance").Eq(&Amount{Yuan: 1})).Get(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), got.Id)
+		assert.Equal(t, Amount{Yuan: 1}, got.Balance)
+
+		affected, err = NewDeleter[FieldTypeModel](db).Where(C("CreateTime").Eq(createTime), C("Nick").IsNull()).
+			Exec(ctx).RowsAffected()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), affected)
+		_ = db.db.Close()
+	}
+}
+
+// Amount 金额，数据库中以分为单位存储
+type Amount struct {
+	Yuan int64
+	Fen  int64
+}
+
+func (a *Amount) Scan(src any) error {
+	val, ok := src.(int64)
+	if !ok {
+		return fmt.Errorf("amount: 不支持的类型 %T", src)
+	}
+	a.Yuan, a.Fen = val/100, val%100
+	return nil
+}
+
+// Value 定义在指针上，插入的时候需要传入字段的指针
+func (a *Amount) Value() (driver.Value, error) {
+	return a.Yuan*100 + a.Fen, nil
+}
+
+type FieldTypeModel struct {
+	Id         int64
+	Name       *string
+	Age        *int64
+	Nick       sql.NullString
+	Score      sql.NullInt64
+	Balance    Amount
+	CreateTime time.Time
+}
+
+func TestDB_JSON(t *testing.T) {
+	for _, opt := range []DBOption{DBUseReflectValuer(), func(db *DB) {}} {
+		db, err := Open("sqlite3", "file:json.db?cache=shared&mode=memory", opt)
+		require.NoError(t, err)
+		ctx := context.Background()
+		_, err = db.db.ExecContext(ctx, "DROP TABLE IF EXISTS `json_model`")
+		require.NoError(t, err)
+		_, err = db.db.ExecContext(ctx, "CREATE TABLE `json_model`("+
+			"`id` INTEGER PRIMARY KEY, `settings` TEXT, `tags` TEXT, `profile` TEXT)")
+		require.NoError(t, err)
+
+		full := &JSONModel{
+			Id:       1,
+			Settings: map[string]string{"theme": "dark"},
+			Tags:     []string{"a", "b"},
+			Profile:  JsonColumn[User]{Val: User{Name: "xiao"}, Valid: true},
+		}
+		// nil 的 map 和 slice 存储为 NULL
+		empty := &JSONModel{Id: 2}
+		_, err = NewInserter[JSONModel](db).Values(full, empty).Exec(ctx).RowsAffected()
+		require.NoError(t, err)
+
+		res, err := NewSelector[JSONModel](db).OrderBy(Asc("Id")).GetMulti(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []*JSONModel{full, empty}, res)
+
+		_, err = NewUpdater[JSONModel](db).Set(C("Tags").Eq([]string{"c"})).
+			Where(C("Id").Eq(2)).Exec(ctx).RowsAffected()
+		require.NoError(t, err)
+		got, err := NewSelector[JSONModel](db).Where(C("Id").Eq(2)).Get(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"c"}, got.Tags)
+
+		// 不合法的 JSON
+		_, err = db.db.ExecContext(ctx, "UPDATE `json_model` SET `settings` = '{' WHERE `id` = 2")
+		require.NoError(t, err)
+		_, err = NewSelector[JSONModel](db).Where(C("Id").Eq(2)).Get(ctx)
+		var jsonErr *JSONError
+		require.True(t, errors.As(err, &jsonErr))
+		assert.Equal(t, "Settings", jsonErr.Field)
+		_ = db.db.Close()
+	}
+}
+
+type JSONModel struct {
+	Id       int64
+	Settings map[string]string `orm:"json"`
+	Tags     []string          `orm:"json"`
+	Profile  JsonColumn[User]
+}
@@ -3,35 +3,53 @@ package morm
 import (
 	"context"
 	"database/sql"
-	"errors"
-	"github.com/soluble1/morm/internal/errs"
 )
 
 type Deleter[T any] struct {
 	builder
-	db *DB
+	sess Session
 
 	where []Predicate
+
+	allowFullTable bool
+	returning      returning
 }
 
 func (d *Deleter[T]) Exec(ctx context.Context) sql.Result {
-	m, err := d.Build()
-	if err != nil {
-		return nil
+	qc := newQueryContext[T](d.core, "DELETE", d)
+	if d.returning.set {
+		return execReturning[T](ctx, d.sess, qc)
 	}
+	return exec(ctx, d.sess, qc)
+}
 
-	execContext, err := d.db.db.ExecContext(ctx, m.SQL, m.Args)
-	return Result{
-		res: execContext,
-		err: err,
-	}
+// Returning 不传入列表示返回所有的列，通过 GetMulti 拿到结果，MySQL 不支持
+func (d *Deleter[T]) Returning(cols ...string) *Deleter[T] {
+	d.returning = returning{set: true, cols: cols}
+	return d
+}
+
+// GetMulti 执行语句并返回 RETURNING 的结果，没有调用 Returning 的时候返回所有的列
+func (d *Deleter[T]) GetMulti(ctx context.Context) ([]*T, error) {
+	d.returning.set = true
+	return returningMulti[T](ctx, d.sess, newQueryContext[T](d.core, "DELETE", d))
+}
+
+// AllowFullTable 没有 WHERE 也可以执行，用于绕过 safety 之类的 Middleware
+func (d *Deleter[T]) AllowFullTable() *Deleter[T] {
+	d.allowFullTable = true
+	return d
+}
+
+func (d *Deleter[T]) IsFullTable() bool {
+	return !d.allowFullTable && len(d.where) == 0
 }
 
-func NewDeleter[T any](db *DB) *Deleter[T] {
+func NewDeleter[T any](sess Session) *Deleter[T] {
 	return &Deleter[T]{
-		db: db,
+		sess: sess,
 		builder: builder{
-			dialect: db.dialect,
+			core: sess.getCore(),
 		},
 	}
 }
@@ -46,87 +64,28 @@ func (d *Deleter[T]) Build() (*Query, error) {
 
 	t := new(T)
 	var err error
-	d.model, err = d.db.r.Get(t)
+	d.model, err = d.r.Get(t)
 	if err != nil {
 		return nil, err
 	}
 
-	d.quote(d.model.TableName)
+	d.quoteTable(d.model)
 
 	if len(d.where) > 0 {
 		d.sb.WriteByte(' ')
 		d.sb.WriteString("WHERE ")
-		t := d.where[0]
-		for i := 1; i < len(d.where); i++ {
-			t = t.And(d.where[i])
-		}
-		err = d.buildExpression(t)
-		if err != nil {
+		if err = d.buildPredicates(d.where); err != nil {
 			return nil, err
 		}
 	}
 
+	if err = d.buildReturning(d.returning); err != nil {
+		return nil, err
+	}
+
 	d.sb.WriteByte(';')
 	return &Query{
 		SQL:  d.sb.String(),
 		Args: d.args,
 	}, nil
 }
-
-func (d *Deleter[T]) buildExpression(expression Expression) error {
-	switch expr := expression.(type) {
-	case nil:
-		return nil
-	case Value:
-		d.sb.WriteByte('?')
-		if d.args == nil {
-			d.args = make([]any, 0, 8)
-		}
-		d.args = append(d.args, expr.val)
-	case Column:
-		d.sb.WriteByte('`')
-		fd, ok := d.model.FieldMap[expr.name]
-		if !ok {
-			return errs.NewErrUnKnowField(expr.name)
-		}
-		d.sb.WriteString(fd.ColName)
-		d.sb.WriteByte('`')
-	case Predicate:
-		P, ok := expr.left.(Predicate)
-		if ok && P.op != opNOT {
-			d.sb.WriteByte('(')
-		}
-		if err := d.buildExpression(expr.left); err != nil {
-			return err
-		}
-		if ok && P.op != opNOT {
-			d.sb.WriteByte(')')
-		}
-
-		if expr.op != opNOT && expr.op != "" {
-			d.sb.WriteByte(' ')
-
-		}
-		d.sb.WriteString(expr.op.String())
-		if expr.op != "" {
-			d.sb.WriteByte(' ')
-		}
-
-		_, ok = expr.right.(Predicate)
-		if ok {
-			d.sb.WriteByte('(')
-		}
-		if err := d.buildExpression(expr.right); err != nil {
-			return err
-		}
-		if ok {
-			d.sb.WriteByte(')')
-		}
-	case RawExpr:
-		d.sb.WriteString(expr.raw)
-		d.args = append(d.args, expr.args...)
-	default:
-		return errors.New("orm: 不支持的表达式")
-	}
-	return nil
-}
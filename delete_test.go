@@ -38,6 +38,14 @@ func TestDeleter_Build(t *testing.T) {
 				Args: []any{23, "xiaolong"},
 			},
 		},
+		{
+			name: "in delete",
+			d:    NewDeleter[TestModel](db).Where(C("Id").In(1, 2), C("LastName").IsNull()),
+			wantQuery: &Query{
+				SQL:  "DELETE FROM `test_model` WHERE (`id` IN (?,?)) AND (`last_name` IS NULL);",
+				Args: []any{1, 2},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1,63 +1,284 @@
 package morm
 
 import (
+	"database/sql"
+	"fmt"
 	"github.com/soluble1/morm/internal/errs"
+	"github.com/soluble1/morm/model"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 )
 
-// Dialect 方言构造部分
+var (
+	DialectMySQL      Dialect = &mysqlDialect{}
+	DialectSQLite     Dialect = &sqliteDialect{}
+	DialectPostgreSQL Dialect = &postgreSQL{}
+)
+
+// Dialect 方言，不同数据库的 SQL 差异都在这里处理
+// 第三方的方言可以组合 StandardSQL，只覆盖有差异的方法
 type Dialect interface {
-	// 引号
-	quoter() byte
-	buildDuplicateKey(b *builder, odk *Upsert) error
+	// Quote 给表名、列名之类的标识符加上引号
+	Quote(name string) string
+	// Placeholder 第 index 个参数的占位符，index 从 1 开始
+	Placeholder(index int) string
+	// Upsert 构造 INSERT 语句后面冲突时更新的部分
+	Upsert(b SQLBuilder, upsert *UpsertSpec) error
+	// LimitOffset 分页，limit 和 offset 小于等于 0 表示没有设置
+	LimitOffset(b SQLBuilder, limit int, offset int)
+	// SupportsReturning 是否支持 RETURNING
+	SupportsReturning() bool
+	// SupportsLastInsertIdBackfill 批量插入的时候 LastInsertId 是否是第一行的 id 并且后面的 id 连续，
+	// 为 true 的时候 Inserter 才能回填自增 id
+	SupportsLastInsertIdBackfill() bool
+	// ColumnType 字段对应的列类型，优先使用 type 标签，无法映射的时候返回空字符串
+	ColumnType(fd *model.Field) string
+	// MaxPlaceholders 一条语句最多的占位符数量，Inserter 自动分批的时候使用
+	MaxPlaceholders() int
 }
 
-// SQL 标准的方言实现
-type standardSQL struct {
+// SQLBuilder Dialect 通过它写入 SQL
+type SQLBuilder interface {
+	// WriteString 原样写入
+	WriteString(s string)
+	// WriteQuoted 写入使用 Dialect.Quote 加上引号的标识符
+	WriteQuoted(name string)
+	// WriteArg 写入占位符并且记录参数
+	WriteArg(val any)
 }
 
-type mysqlDialect struct {
-	standardSQL
+// UpsertSpec 解析之后的 Upsert，字段名都已经转换为列名
+type UpsertSpec struct {
+	// ConflictColumns 冲突的列，MySQL 不需要
+	ConflictColumns []string
+	Assigns         []UpsertAssign
+}
+
+// UpsertAssign 冲突时更新的列，UseInserted 为 true 表示使用 INSERT 中的值，否则使用 Val
+type UpsertAssign struct {
+	Column      string
+	Val         any
+	UseInserted bool
+}
+
+// sqlWriter 把 builder 包装为 SQLBuilder，避免 builder 的方法暴露到各个构造器上
+type sqlWriter struct {
+	b *builder
+}
+
+func (w sqlWriter) WriteString(s string) {
+	w.b.sb.WriteString(s)
+}
+
+func (w sqlWriter) WriteQuoted(name string) {
+	w.b.quote(name)
+}
+
+func (w sqlWriter) WriteArg(val any) {
+	w.b.addArg(val)
+}
+
+// StandardSQL SQL 标准的方言实现，不支持 Upsert 和 RETURNING
+type StandardSQL struct {
+}
+
+// Quote 使用双引号，标识符中的双引号会被转义
+func (StandardSQL) Quote(name string) string {
+	return quoteWith(name, '"')
+}
+
+func (StandardSQL) Placeholder(index int) string {
+	return "?"
+}
+
+func (StandardSQL) Upsert(b SQLBuilder, upsert *UpsertSpec) error {
+	return errs.ErrUnsupportedUpsert
+}
+
+func (StandardSQL) LimitOffset(b SQLBuilder, limit int, offset int) {
+	if limit > 0 {
+		b.WriteString(" LIMIT ")
+		b.WriteArg(limit)
+	}
+	if offset > 0 {
+		b.WriteString(" OFFSET ")
+		b.WriteArg(offset)
+	}
+}
+
+func (StandardSQL) SupportsReturning() bool {
+	return false
+}
+
+func (StandardSQL) SupportsLastInsertIdBackfill() bool {
+	return false
+}
+
+// MaxPlaceholders 不知道具体数据库的时候使用比较保守的 999
+func (StandardSQL) MaxPlaceholders() int {
+	return 999
+}
+
+func (StandardSQL) ColumnType(fd *model.Field) string {
+	return columnType(fd, map[reflect.Kind]string{
+		reflect.Bool:    "BOOLEAN",
+		reflect.Int8:    "SMALLINT",
+		reflect.Int16:   "SMALLINT",
+		reflect.Int32:   "INTEGER",
+		reflect.Int:     "BIGINT",
+		reflect.Int64:   "BIGINT",
+		reflect.Uint8:   "SMALLINT",
+		reflect.Uint16:  "INTEGER",
+		reflect.Uint32:  "BIGINT",
+		reflect.Uint:    "BIGINT",
+		reflect.Uint64:  "BIGINT",
+		reflect.Float32: "REAL",
+		reflect.Float64: "DOUBLE PRECISION",
+		reflect.String:  "VARCHAR",
+	}, "BLOB", "TIMESTAMP", "TEXT")
+}
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	bytesType = reflect.TypeOf([]byte{})
+	// nullTypes sql.NullXxx 对应的基本类型
+	nullTypes = map[reflect.Type]reflect.Type{
+		reflect.TypeOf(sql.NullBool{}):    reflect.TypeOf(false),
+		reflect.TypeOf(sql.NullByte{}):    reflect.TypeOf(uint8(0)),
+		reflect.TypeOf(sql.NullInt16{}):   reflect.TypeOf(int16(0)),
+		reflect.TypeOf(sql.NullInt32{}):   reflect.TypeOf(int32(0)),
+		reflect.TypeOf(sql.NullInt64{}):   reflect.TypeOf(int64(0)),
+		reflect.TypeOf(sql.NullFloat64{}): reflect.TypeOf(float64(0)),
+		reflect.TypeOf(sql.NullString{}):  reflect.TypeOf(""),
+		reflect.TypeOf(sql.NullTime{}):    timeType,
+	}
+)
+
+// columnType 各个方言共用的类型映射，kinds 是基本类型对应的列类型
+// 字符串设置了 size 的时候使用 VARCHAR(size)，否则使用 text
+func columnType(fd *model.Field, kinds map[reflect.Kind]string, bytes, timestamp, text string) string {
+	if fd.SQLType != "" {
+		return fd.SQLType
+	}
+	if fd.JSON {
+		return text
+	}
+	typ := fd.Typ
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if base, ok := nullTypes[typ]; ok {
+		typ = base
+	}
+	switch {
+	case typ == timeType:
+		return timestamp
+	case typ == bytesType:
+		return bytes
+	case typ.Kind() == reflect.String:
+		if fd.Size > 0 {
+			return fmt.Sprintf("%s(%d)", kinds[reflect.String], fd.Size)
+		}
+		return text
+	}
+	return kinds[typ.Kind()]
 }
 
-func (dialect *mysqlDialect) quoter() byte {
-	return '`'
+// quoteWith 标识符中的引号使用两个引号转义
+func quoteWith(name string, quote byte) string {
+	q := string(quote)
+	return q + strings.ReplaceAll(name, q, q+q) + q
 }
 
-func (dialect *mysqlDialect) buildDuplicateKey(b *builder, odk *Upsert) error {
-	b.sb.WriteString(" ON DUPLICATE KEY UPDATE ")
-	for idx, assign := range odk.assigns {
+// buildUpsertAssigns 各个方言的 Upsert 只有引用插入的值的写法不同
+func buildUpsertAssigns(b SQLBuilder, upsert *UpsertSpec, inserted func(col string)) {
+	for idx, assign := range upsert.Assigns {
 		if idx > 0 {
-			b.sb.WriteByte(',')
+			b.WriteString(",")
 		}
-		switch expr := assign.(type) {
-		case Assignment:
-			fd, ok := b.model.FieldMap[expr.column]
-			if !ok {
-				return errs.NewErrUnKnowField(expr.column)
-			}
-			b.quote(fd.ColName)
-			b.sb.WriteString("=?")
-			b.args = append(b.args, expr.val)
-		case Column:
-			fd, ok := b.model.FieldMap[expr.name]
-			if !ok {
-				return errs.NewErrUnKnowField(expr.name)
-			}
-			b.quote(fd.ColName)
-			b.sb.WriteString("=VALUES(")
-			b.quote(fd.ColName)
-			b.sb.WriteByte(')')
+		b.WriteQuoted(assign.Column)
+		b.WriteString("=")
+		if assign.UseInserted {
+			inserted(assign.Column)
+		} else {
+			b.WriteArg(assign.Val)
 		}
 	}
+}
+
+type mysqlDialect struct {
+	StandardSQL
+}
+
+func (dialect *mysqlDialect) Quote(name string) string {
+	return quoteWith(name, '`')
+}
+
+// LimitOffset MySQL 不支持单独使用 OFFSET，没有 LIMIT 的时候用最大值代替
+func (dialect *mysqlDialect) LimitOffset(b SQLBuilder, limit int, offset int) {
+	if limit <= 0 && offset > 0 {
+		b.WriteString(" LIMIT 18446744073709551615")
+	}
+	dialect.StandardSQL.LimitOffset(b, limit, offset)
+}
+
+// SupportsLastInsertIdBackfill innodb_autoinc_lock_mode 为 0 或者 1 的时候批量插入的 id 是连续的
+func (dialect *mysqlDialect) SupportsLastInsertIdBackfill() bool {
+	return true
+}
+
+func (dialect *mysqlDialect) MaxPlaceholders() int {
+	return 65535
+}
+
+func (dialect *mysqlDialect) Upsert(b SQLBuilder, upsert *UpsertSpec) error {
+	b.WriteString(" ON DUPLICATE KEY UPDATE ")
+	buildUpsertAssigns(b, upsert, func(col string) {
+		b.WriteString("VALUES(")
+		b.WriteQuoted(col)
+		b.WriteString(")")
+	})
 	return nil
 }
 
+func (dialect *mysqlDialect) ColumnType(fd *model.Field) string {
+	if fd.JSON && fd.SQLType == "" {
+		return "JSON"
+	}
+	return columnType(fd, map[reflect.Kind]string{
+		reflect.Bool:    "TINYINT(1)",
+		reflect.Int8:    "TINYINT",
+		reflect.Int16:   "SMALLINT",
+		reflect.Int32:   "INT",
+		reflect.Int:     "BIGINT",
+		reflect.Int64:   "BIGINT",
+		reflect.Uint8:   "TINYINT UNSIGNED",
+		reflect.Uint16:  "SMALLINT UNSIGNED",
+		reflect.Uint32:  "INT UNSIGNED",
+		reflect.Uint:    "BIGINT UNSIGNED",
+		reflect.Uint64:  "BIGINT UNSIGNED",
+		reflect.Float32: "FLOAT",
+		reflect.Float64: "DOUBLE",
+		reflect.String:  "VARCHAR",
+	}, "BLOB", "DATETIME", "TEXT")
+}
+
 type sqliteDialect struct {
-	standardSQL
+	StandardSQL
 }
 
-func (dialect *sqliteDialect) quoter() byte {
-	return '`'
+func (dialect *sqliteDialect) Quote(name string) string {
+	return quoteWith(name, '`')
+}
+
+// LimitOffset SQLite 同样需要 LIMIT 才能使用 OFFSET，-1 表示不限制
+func (dialect *sqliteDialect) LimitOffset(b SQLBuilder, limit int, offset int) {
+	if limit <= 0 && offset > 0 {
+		b.WriteString(" LIMIT -1")
+	}
+	dialect.StandardSQL.LimitOffset(b, limit, offset)
 }
 
 /*
@@ -66,50 +287,117 @@ https://www.sqlite.org/lang_UPSERT.html
 		INSERT INTO phonebook(name,phonenumber) VALUES('Alice','704-555-1212')
 	  		ON CONFLICT(name) DO UPDATE SET phonenumber=excluded.phonenumber;
 */
-func (dialect *sqliteDialect) buildDuplicateKey(b *builder, odk *Upsert) error {
-	b.sb.WriteString(" ON CONFLICT")
-	if len(odk.conflictColumns) > 0 {
-		b.sb.WriteString(" (")
-		for i, col := range odk.conflictColumns {
+func (dialect *sqliteDialect) Upsert(b SQLBuilder, upsert *UpsertSpec) error {
+	buildOnConflict(b, upsert, "excluded.")
+	return nil
+}
+
+// buildOnConflict SQLite 和 PostgreSQL 的 Upsert，excluded 是引用插入的值时的前缀
+func buildOnConflict(b SQLBuilder, upsert *UpsertSpec, excluded string) {
+	b.WriteString(" ON CONFLICT")
+	if len(upsert.ConflictColumns) > 0 {
+		b.WriteString(" (")
+		for i, col := range upsert.ConflictColumns {
 			if i > 0 {
-				b.sb.WriteByte(',')
+				b.WriteString(",")
 			}
-			fd, ok := b.model.FieldMap[col]
-			if !ok {
-				return errs.NewErrUnKnowField(col)
-			}
-			b.quote(fd.ColName)
+			b.WriteQuoted(col)
 		}
-		b.sb.WriteByte(')')
+		b.WriteString(")")
 	}
-	b.sb.WriteString(" DO UPDATE SET ")
-	for idx, assign := range odk.assigns {
-		if idx > 0 {
-			b.sb.WriteByte(',')
-		}
-		switch expr := assign.(type) {
-		case Assignment:
-			fd, ok := b.model.FieldMap[expr.column]
-			if !ok {
-				return errs.NewErrUnKnowField(expr.column)
-			}
-			b.quote(fd.ColName)
-			b.sb.WriteString("=?")
-			b.args = append(b.args, expr.val)
-		case Column:
-			fd, ok := b.model.FieldMap[expr.name]
-			if !ok {
-				return errs.NewErrUnKnowField(expr.name)
-			}
-			b.quote(fd.ColName)
-			b.sb.WriteString("=excluded.")
-			b.quote(fd.ColName)
-		}
+	b.WriteString(" DO UPDATE SET ")
+	buildUpsertAssigns(b, upsert, func(col string) {
+		b.WriteString(excluded)
+		b.WriteQuoted(col)
+	})
+}
+
+// SupportsReturning SQLite 3.35 开始支持 RETURNING
+func (dialect *sqliteDialect) SupportsReturning() bool {
+	return true
+}
+
+// MaxPlaceholders SQLite 3.32.0 之后是 32766，之前是 999
+func (dialect *sqliteDialect) MaxPlaceholders() int {
+	return 32766
+}
+
+// ColumnType SQLite 只有几种存储类型
+func (dialect *sqliteDialect) ColumnType(fd *model.Field) string {
+	return columnType(fd, map[reflect.Kind]string{
+		reflect.Bool:    "INTEGER",
+		reflect.Int8:    "INTEGER",
+		reflect.Int16:   "INTEGER",
+		reflect.Int32:   "INTEGER",
+		reflect.Int:     "INTEGER",
+		reflect.Int64:   "INTEGER",
+		reflect.Uint8:   "INTEGER",
+		reflect.Uint16:  "INTEGER",
+		reflect.Uint32:  "INTEGER",
+		reflect.Uint:    "INTEGER",
+		reflect.Uint64:  "INTEGER",
+		reflect.Float32: "REAL",
+		reflect.Float64: "REAL",
+		reflect.String:  "VARCHAR",
+	}, "BLOB", "DATETIME", "TEXT")
+}
+
+type postgreSQL struct {
+	StandardSQL
+}
+
+// Placeholder PostgreSQL 使用 $1、$2 这样的占位符
+func (dialect *postgreSQL) Placeholder(index int) string {
+	return "$" + strconv.Itoa(index)
+}
+
+// Upsert PostgreSQL 的 DO UPDATE 必须指定冲突的列
+//
+//	INSERT INTO "user"("id","name") VALUES($1,$2)
+//		ON CONFLICT ("id") DO UPDATE SET "name"=EXCLUDED."name";
+func (dialect *postgreSQL) Upsert(b SQLBuilder, upsert *UpsertSpec) error {
+	if len(upsert.ConflictColumns) == 0 {
+		return errs.ErrNoConflictColumns
 	}
+	buildOnConflict(b, upsert, "EXCLUDED.")
 	return nil
 }
 
-// postgreSQL 的 DuplicateKey 和 sqlite 的一样，但是引号不同
-type postgreSQL struct {
-	standardSQL
+func (dialect *postgreSQL) SupportsReturning() bool {
+	return true
+}
+
+func (dialect *postgreSQL) MaxPlaceholders() int {
+	return 65535
+}
+
+// ColumnType 自增的整数使用 SERIAL 和 BIGSERIAL
+func (dialect *postgreSQL) ColumnType(fd *model.Field) string {
+	if fd.SQLType == "" && fd.AutoIncrement {
+		switch fd.Typ.Kind() {
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+			return "SERIAL"
+		case reflect.Int, reflect.Int64, reflect.Uint32, reflect.Uint, reflect.Uint64:
+			return "BIGSERIAL"
+		}
+	}
+	if fd.JSON && fd.SQLType == "" {
+		return "JSONB"
+	}
+	return columnType(fd, map[reflect.Kind]string{
+		reflect.Bool:    "BOOLEAN",
+		reflect.Int8:    "SMALLINT",
+		reflect.Int16:   "SMALLINT",
+		reflect.Int32:   "INTEGER",
+		reflect.Int:     "BIGINT",
+		reflect.Int64:   "BIGINT",
+		reflect.Uint8:   "SMALLINT",
+		reflect.Uint16:  "INTEGER",
+		reflect.Uint32:  "BIGINT",
+		reflect.Uint:    "NUMERIC(20)",
+		reflect.Uint64:  "NUMERIC(20)",
+		reflect.Float32: "REAL",
+		reflect.Float64: "DOUBLE PRECISION",
+		reflect.String:  "VARCHAR",
+	}, "BYTEA", "TIMESTAMP", "TEXT")
 }
@@ -0,0 +1,360 @@
+package morm
+
+import (
+	"database/sql"
+	"github.com/soluble1/morm/internal/errs"
+	"github.com/soluble1/morm/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDialect_Quote(t *testing.T) {
+	assert.Equal(t, "`user`", DialectMySQL.Quote("user"))
+	assert.Equal(t, "`us``er`", DialectSQLite.Quote("us`er"))
+	assert.Equal(t, `"us""er"`, DialectPostgreSQL.Quote(`us"er`))
+}
+
+func TestDialect_ColumnType(t *testing.T) {
+	field := func(val any, opts ...func(fd *model.Field)) *model.Field {
+		fd := &model.Field{Typ: reflect.TypeOf(val)}
+		for _, opt := range opts {
+			opt(fd)
+		}
+		return fd
+	}
+	tests := []struct {
+		name       string
+		fd         *model.Field
+		wantMySQL  string
+		wantSQLite string
+		wantStd    string
+		wantPG     string
+	}{
+		{
+			name:       "int64",
+			fd:         field(int64(0)),
+			wantMySQL:  "BIGINT",
+			wantSQLite: "INTEGER",
+			wantStd:    "BIGINT",
+			wantPG:     "BIGINT",
+		},
+		{
+			name:       "pointer",
+			fd:         field(new(uint8)),
+			wantMySQL:  "TINYINT UNSIGNED",
+			wantSQLite: "INTEGER",
+			wantStd:    "SMALLINT",
+			wantPG:     "SMALLINT",
+		},
+		{
+			name:       "null string with size",
+			fd:         field(sql.NullString{}, func(fd *model.Field) { fd.Size = 64 }),
+			wantMySQL:  "VARCHAR(64)",
+			wantSQLite: "VARCHAR(64)",
+			wantStd:    "VARCHAR(64)",
+			wantPG:     "VARCHAR(64)",
+		},
+		{
+			name:       "string",
+			fd:         field(""),
+			wantMySQL:  "TEXT",
+			wantSQLite: "TEXT",
+			wantStd:    "TEXT",
+			wantPG:     "TEXT",
+		},
+		{
+			name:       "time",
+			fd:         field(time.Time{}),
+			wantMySQL:  "DATETIME",
+			wantSQLite: "DATETIME",
+			wantStd:    "TIMESTAMP",
+			wantPG:     "TIMESTAMP",
+		},
+		{
+			name:       "bytes",
+			fd:         field([]byte{}),
+			wantMySQL:  "BLOB",
+			wantSQLite: "BLOB",
+			wantStd:    "BLOB",
+			wantPG:     "BYTEA",
+		},
+		{
+			name:       "json",
+			fd:         field(map[string]string{}, func(fd *model.Field) { fd.JSON = true }),
+			wantMySQL:  "JSON",
+			wantSQLite: "TEXT",
+			wantStd:    "TEXT",
+			wantPG:     "JSONB",
+		},
+		{
+			name:       "type tag",
+			fd:         field(0, func(fd *model.Field) { fd.SQLType = "decimal(10,2)" }),
+			wantMySQL:  "decimal(10,2)",
+			wantSQLite: "decimal(10,2)",
+			wantStd:    "decimal(10,2)",
+			wantPG:     "decimal(10,2)",
+		},
+		{
+			name:       "auto increment",
+			fd:         field(int64(0), func(fd *model.Field) { fd.AutoIncrement = true }),
+			wantMySQL:  "BIGINT",
+			wantSQLite: "INTEGER",
+			wantStd:    "BIGINT",
+			wantPG:     "BIGSERIAL",
+		},
+		{
+			name: "unknown",
+			fd:   field(Amount{}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantMySQL, DialectMySQL.ColumnType(tt.fd))
+			assert.Equal(t, tt.wantSQLite, DialectSQLite.ColumnType(tt.fd))
+			assert.Equal(t, tt.wantStd, StandardSQL{}.ColumnType(tt.fd))
+			assert.Equal(t, tt.wantPG, DialectPostgreSQL.ColumnType(tt.fd))
+		})
+	}
+}
+
+// oracleDialect 第三方方言，只覆盖有差异的部分
+type oracleDialect struct {
+	StandardSQL
+}
+
+func (oracleDialect) Placeholder(index int) string {
+	return ":" + strconv.Itoa(index)
+}
+
+func (oracleDialect) LimitOffset(b SQLBuilder, limit int, offset int) {
+	if offset > 0 {
+		b.WriteString(" OFFSET ")
+		b.WriteArg(offset)
+		b.WriteString(" ROWS")
+	}
+	if limit > 0 {
+		b.WriteString(" FETCH NEXT ")
+		b.WriteArg(limit)
+		b.WriteString(" ROWS ONLY")
+	}
+}
+
+func TestDialect_Custom(t *testing.T) {
+	db, err := OpenDB(memoryDB(t).db, DBWithDialect(oracleDialect{}))
+	require.NoError(t, err)
+	tests := []struct {
+		name      string
+		b         QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "select",
+			b:    NewSelector[TestModel](db).Where(C("Id").Eq(1)).Limit(10).Offset(20),
+			wantQuery: &Query{
+				SQL:  `SELECT * FROM "test_model" WHERE "id" = :1 OFFSET :2 ROWS FETCH NEXT :3 ROWS ONLY;`,
+				Args: []any{1, 20, 10},
+			},
+		},
+		{
+			name: "insert",
+			b:    NewInserter[TestModel](db).Values(&TestModel{Id: 1, Age: 18}).Columns("Id", "Age"),
+			wantQuery: &Query{
+				SQL:  `INSERT INTO "test_model"("id","age") VALUES(:1,:2);`,
+				Args: []any{int64(1), int8(18)},
+			},
+		},
+		{
+			name:    "upsert",
+			b:       NewInserter[TestModel](db).Values(&TestModel{}).Upsert().Update(C("Age")),
+			wantErr: errs.ErrUnsupportedUpsert,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := tt.b.Build()
+			assert.Equal(t, tt.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.wantQuery, q)
+		})
+	}
+}
+
+func TestDialect_SQLiteUpsert(t *testing.T) {
+	db, err := OpenDB(memoryDB(t).db, DBWithDialect(DialectSQLite))
+	require.NoError(t, err)
+	tests := []struct {
+		name      string
+		b         QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "upsert",
+			b: NewInserter[TestModel](db).Values(&TestModel{Id: 1, Age: 18}).Columns("Id", "Age").
+				Upsert().ConflictColumns("Id").Update(C("Age"), Assign("FirstName", "xiao")),
+			wantQuery: &Query{
+				SQL: "INSERT INTO `test_model`(`id`,`age`) VALUES(?,?) " +
+					"ON CONFLICT (`id`) DO UPDATE SET `age`=excluded.`age`,`first_name`=?;",
+				Args: []any{int64(1), int8(18), "xiao"},
+			},
+		},
+		{
+			name: "unknown conflict column",
+			b: NewInserter[TestModel](db).Values(&TestModel{}).
+				Upsert().ConflictColumns("Invalid").Update(C("Age")),
+			wantErr: errs.NewErrUnKnowField("Invalid"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := tt.b.Build()
+			assert.Equal(t, tt.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.wantQuery, q)
+		})
+	}
+}
+
+func TestDialect_PostgreSQL(t *testing.T) {
+	db, err := OpenDB(memoryDB(t).db, DBWithDialect(DialectPostgreSQL))
+	require.NoError(t, err)
+	tests := []struct {
+		name      string
+		b         QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "select where",
+			b: NewSelector[TestModel](db).Select(C("Id"), Avg("Age").As("avg_age")).
+				Where(C("Id").In(1, 2), C("FirstName").Like("xiao%")).
+				GroupBy(C("Id")).Having(Avg("Age").Gt(18)).OrderBy(Desc("Id")).Limit(10).Offset(20),
+			wantQuery: &Query{
+				SQL: `SELECT "id",AVG("age") AS "avg_age" FROM "test_model" ` +
+					`WHERE ("id" IN ($1,$2)) AND ("first_name" LIKE $3) ` +
+					`GROUP BY "id" HAVING AVG("age") > $4 ORDER BY "id" DESC LIMIT $5 OFFSET $6;`,
+				Args: []any{1, 2, "xiao%", 18, 10, 20},
+			},
+		},
+		{
+			name: "select offset only",
+			b:    NewSelector[TestModel](db).Offset(20),
+			wantQuery: &Query{
+				SQL:  `SELECT * FROM "test_model" OFFSET $1;`,
+				Args: []any{20},
+			},
+		},
+		{
+			name: "select subquery",
+			b: func() QueryBuilder {
+				sub := NewSelector[TestModel](db).Select(C("Id")).Where(C("Age").Gt(18)).AsSubquery("sub")
+				return NewSelector[TestModel](db).Where(C("FirstName").Eq("xiao"), C("Id").InQuery(sub),
+					C("Age").Lt(60))
+			}(),
+			wantQuery: &Query{
+				SQL: `SELECT * FROM "test_model" WHERE (("first_name" = $1) AND ` +
+					`("id" IN (SELECT "id" FROM "test_model" WHERE "age" > $2))) AND ("age" < $3);`,
+				Args: []any{"xiao", 18, 60},
+			},
+		},
+		{
+			name: "select from subquery",
+			b: func() QueryBuilder {
+				sub := NewSelector[TestModel](db).Where(C("Age").Gt(18)).Limit(5).AsSubquery("sub")
+				return NewSelector[TestModel](db).Select(sub.C("Id")).From(sub).Where(sub.C("Id").Gt(1))
+			}(),
+			wantQuery: &Query{
+				SQL: `SELECT "sub"."id" FROM (SELECT * FROM "test_model" WHERE "age" > $1 LIMIT $2) AS "sub" ` +
+					`WHERE "sub"."id" > $3;`,
+				Args: []any{18, 5, 1},
+			},
+		},
+		{
+			// 同一个子查询使用两次，占位符的序号和参数都要正确
+			name: "select subquery twice",
+			b: func() QueryBuilder {
+				sub := NewSelector[TestModel](db).Select(C("Id")).Where(C("Age").Gt(10)).AsSubquery("sub")
+				return NewSelector[TestModel](db).Where(C("Age").Gt(1), C("Id").InQuery(sub), C("Id").InQuery(sub))
+			}(),
+			wantQuery: &Query{
+				SQL: `SELECT * FROM "test_model" WHERE (("age" > $1) AND ` +
+					`("id" IN (SELECT "id" FROM "test_model" WHERE "age" > $2))) AND ` +
+					`("id" IN (SELECT "id" FROM "test_model" WHERE "age" > $3));`,
+				Args: []any{1, 10, 10},
+			},
+		},
+		{
+			name: "select raw",
+			b: NewSelector[TestModel](db).Select(Raw(`COUNT(DISTINCT "age")`)).
+				Where(C("Id").Gt(1), Raw(`"age" BETWEEN ? AND ?`, 18, 60).AsPredicate()),
+			wantQuery: &Query{
+				SQL:  `SELECT COUNT(DISTINCT "age") FROM "test_model" WHERE ("id" > $1) AND ("age" BETWEEN $2 AND $3);`,
+				Args: []any{1, 18, 60},
+			},
+		},
+		{
+			name: "insert",
+			b: NewInserter[TestModel](db).Values(&TestModel{Id: 1, Age: 18}, &TestModel{Id: 2, Age: 19}).
+				Columns("Id", "Age"),
+			wantQuery: &Query{
+				SQL:  `INSERT INTO "test_model"("id","age") VALUES($1,$2),($3,$4);`,
+				Args: []any{int64(1), int8(18), int64(2), int8(19)},
+			},
+		},
+		{
+			name: "upsert",
+			b: NewInserter[TestModel](db).Values(&TestModel{Id: 1, Age: 18}).Columns("Id", "Age").
+				Upsert().ConflictColumns("Id").Update(C("Age"), Assign("FirstName", "xiao")),
+			wantQuery: &Query{
+				SQL: `INSERT INTO "test_model"("id","age") VALUES($1,$2) ` +
+					`ON CONFLICT ("id") DO UPDATE SET "age"=EXCLUDED."age","first_name"=$3;`,
+				Args: []any{int64(1), int8(18), "xiao"},
+			},
+		},
+		{
+			name:    "upsert without conflict columns",
+			b:       NewInserter[TestModel](db).Values(&TestModel{}).Upsert().Update(C("Age")),
+			wantErr: errs.ErrNoConflictColumns,
+		},
+		{
+			name: "update",
+			b: NewUpdater[TestModel](db).Set(C("Age").Eq(C("Age").Add(1)), C("FirstName").Eq("xiao")).
+				Where(C("Id").Eq(1)),
+			wantQuery: &Query{
+				SQL:  `UPDATE "test_model" SET "age" = "age" + $1, "first_name" = $2 WHERE "id" = $3;`,
+				Args: []any{1, "xiao", 1},
+			},
+		},
+		{
+			name: "delete",
+			b: func() QueryBuilder {
+				sub := NewSelector[TestModel](db).Select(C("Id")).Where(C("Age").Lt(18)).AsSubquery("sub")
+				return NewDeleter[TestModel](db).Where(C("FirstName").Eq("xiao").Or(Exists(sub)))
+			}(),
+			wantQuery: &Query{
+				SQL: `DELETE FROM "test_model" WHERE ("first_name" = $1) OR ` +
+					`(EXISTS (SELECT "id" FROM "test_model" WHERE "age" < $2));`,
+				Args: []any{"xiao", 18},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := tt.b.Build()
+			assert.Equal(t, tt.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.wantQuery, q)
+		})
+	}
+}
@@ -4,4 +4,15 @@ import "github.com/soluble1/morm/internal/errs"
 
 var (
 	ErrNoRows = errs.ErrNoRows
+	// ErrFullTableWrite UPDATE 或者 DELETE 没有 WHERE，并且没有调用 AllowFullTable
+	ErrFullTableWrite = errs.ErrFullTableWrite
+	// ErrSelectNoLimit 大表的 SELECT 没有 LIMIT，并且没有调用 AllowFullTable
+	ErrSelectNoLimit = errs.ErrSelectNoLimit
+	// ErrUnsupportedReturning 方言不支持 RETURNING，例如 MySQL
+	ErrUnsupportedReturning = errs.ErrUnsupportedReturning
+	// ErrNoResult Middleware 没有返回错误，也没有返回 sql.Result
+	ErrNoResult = errs.ErrNoResult
 )
+
+// JSONError JSON 列编码或者解码失败
+type JSONError = errs.JSONError
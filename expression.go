@@ -18,6 +18,11 @@ func (r RawExpr) selectable() {}
 
 func (r RawExpr) expr() {}
 
+// tableAlias 让 RawExpr 可以直接作为表使用，例如 From(Raw("`db`.`user`"))
+func (r RawExpr) tableAlias() string {
+	return ""
+}
+
 func Raw(raw string, args ...any) RawExpr {
 	return RawExpr{
 		raw:  raw,
@@ -30,3 +35,186 @@ func (r RawExpr) AsPredicate() Predicate {
 		left: r,
 	}
 }
+
+// MathExpr 算术表达式，例如 C("Price").Multi(C("Quantity"))
+// 可以用在 SELECT、WHERE 和 UPDATE 的 SET 中
+type MathExpr struct {
+	left  Expression
+	op    op
+	right Expression
+	alias string
+}
+
+func (MathExpr) expr() {}
+
+func (MathExpr) selectable() {}
+
+func (m MathExpr) Add(val any) MathExpr {
+	return MathExpr{
+		left:  m,
+		op:    opADD,
+		right: valueOf(val),
+	}
+}
+
+func (m MathExpr) Sub(val any) MathExpr {
+	return MathExpr{
+		left:  m,
+		op:    opSUB,
+		right: valueOf(val),
+	}
+}
+
+func (m MathExpr) Multi(val any) MathExpr {
+	return MathExpr{
+		left:  m,
+		op:    opMULTI,
+		right: valueOf(val),
+	}
+}
+
+func (m MathExpr) Div(val any) MathExpr {
+	return MathExpr{
+		left:  m,
+		op:    opDIV,
+		right: valueOf(val),
+	}
+}
+
+// As 别名，只在 SELECT 部分生效
+func (m MathExpr) As(alias string) MathExpr {
+	return MathExpr{
+		left:  m.left,
+		op:    m.op,
+		right: m.right,
+		alias: alias,
+	}
+}
+
+func (m MathExpr) Eq(arg any) Predicate {
+	return Predicate{
+		left:  m,
+		op:    opEQ,
+		right: valueOf(arg),
+	}
+}
+
+func (m MathExpr) NotEq(arg any) Predicate {
+	return Predicate{
+		left:  m,
+		op:    opNEQ,
+		right: valueOf(arg),
+	}
+}
+
+func (m MathExpr) Lt(arg any) Predicate {
+	return Predicate{
+		left:  m,
+		op:    opLT,
+		right: valueOf(arg),
+	}
+}
+
+func (m MathExpr) LtEq(arg any) Predicate {
+	return Predicate{
+		left:  m,
+		op:    opLTEQ,
+		right: valueOf(arg),
+	}
+}
+
+func (m MathExpr) Gt(arg any) Predicate {
+	return Predicate{
+		left:  m,
+		op:    opGT,
+		right: valueOf(arg),
+	}
+}
+
+func (m MathExpr) GtEq(arg any) Predicate {
+	return Predicate{
+		left:  m,
+		op:    opGTEQ,
+		right: valueOf(arg),
+	}
+}
+
+// FuncExpr SQL 函数调用，例如 Func("COALESCE", C("Age"), 0)
+type FuncExpr struct {
+	name  string
+	args  []Expression
+	alias string
+}
+
+func (FuncExpr) expr() {}
+
+func (FuncExpr) selectable() {}
+
+// Func 参数可以是值，也可以是 Column 之类的表达式
+func Func(name string, args ...any) FuncExpr {
+	exprs := make([]Expression, 0, len(args))
+	for _, arg := range args {
+		exprs = append(exprs, valueOf(arg))
+	}
+	return FuncExpr{
+		name: name,
+		args: exprs,
+	}
+}
+
+// As 别名，只在 SELECT 部分生效
+func (f FuncExpr) As(alias string) FuncExpr {
+	return FuncExpr{
+		name:  f.name,
+		args:  f.args,
+		alias: alias,
+	}
+}
+
+func (f FuncExpr) Eq(arg any) Predicate {
+	return Predicate{
+		left:  f,
+		op:    opEQ,
+		right: valueOf(arg),
+	}
+}
+
+func (f FuncExpr) NotEq(arg any) Predicate {
+	return Predicate{
+		left:  f,
+		op:    opNEQ,
+		right: valueOf(arg),
+	}
+}
+
+func (f FuncExpr) Lt(arg any) Predicate {
+	return Predicate{
+		left:  f,
+		op:    opLT,
+		right: valueOf(arg),
+	}
+}
+
+func (f FuncExpr) LtEq(arg any) Predicate {
+	return Predicate{
+		left:  f,
+		op:    opLTEQ,
+		right: valueOf(arg),
+	}
+}
+
+func (f FuncExpr) Gt(arg any) Predicate {
+	return Predicate{
+		left:  f,
+		op:    opGT,
+		right: valueOf(arg),
+	}
+}
+
+func (f FuncExpr) GtEq(arg any) Predicate {
+	return Predicate{
+		left:  f,
+		op:    opGTEQ,
+		right: valueOf(arg),
+	}
+}
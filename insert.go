@@ -3,38 +3,172 @@ package morm
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"github.com/soluble1/morm/internal/errs"
+	"github.com/soluble1/morm/internal/valuer"
 	"github.com/soluble1/morm/model"
+	"reflect"
 )
 
 type Inserter[T any] struct {
 	builder
-	db      *DB
+	sess    Session
 	values  []*T
 	columns []string
 
 	onDuplicate *Upsert
+	returning   returning
+
+	fillID bool
+	// fillField 需要回填的自增字段，Build 之后才确定，插入时指定了自增列则为 nil
+	fillField *model.Field
+
+	batchSize int
+	autoBatch bool
+	batchInTx bool
+	// rowArgs 每一行的参数个数，Build 之后才确定
+	rowArgs int
 }
 
+// Exec 设置了 BatchSize 或者 AutoBatch 的时候分批执行，否则只执行一条语句
 func (i *Inserter[T]) Exec(ctx context.Context) sql.Result {
-	q, err := i.Build()
+	if i.batchSize > 0 || i.autoBatch {
+		return i.execBatch(ctx)
+	}
+	return i.exec(ctx)
+}
+
+func (i *Inserter[T]) exec(ctx context.Context) Result {
+	qc := newQueryContext[T](i.core, "INSERT", i)
+	if i.returning.set {
+		return i.execReturning(ctx, qc)
+	}
+	res := exec(ctx, i.sess, qc)
+	if i.fillField == nil || res.err != nil {
+		return res
+	}
+	if err := i.fillAutoIncrement(res); err != nil {
+		return Result{res: res.res, err: err}
+	}
+	return res
+}
+
+// FillAutoIncrementID 执行之后把自增主键回填到 Values 传入的结构体中，不需要重新查询
+// 第一个结构体的 id 是 LastInsertId，后面的依次加一，
+// 只在方言的 SupportsLastInsertIdBackfill 为 true 时有效，例如 MySQL 的自增锁模式保证批量插入的 id 连续的时候，
+// 不支持 Upsert，其它方言请使用 Returning
+func (i *Inserter[T]) FillAutoIncrementID() *Inserter[T] {
+	i.fillID = true
+	return i
+}
+
+// fillAutoIncrement MySQL 的 LastInsertId 是批量插入的第一行的 id
+func (i *Inserter[T]) fillAutoIncrement(res Result) error {
+	id, err := res.LastInsertId()
 	if err != nil {
-		return Result{
-			err: err,
+		return err
+	}
+	var val valuer.Value
+	for idx, v := range i.values {
+		if val == nil {
+			val = i.valCreator(v, i.model)
+		} else {
+			val.Reset(v)
+		}
+		if err = val.SetField(i.fillField.GoName, id+int64(idx)); err != nil {
+			return err
 		}
 	}
-	res, err := i.db.db.ExecContext(ctx, q.SQL, q.Args...)
-	return Result{
-		res: res,
-		err: err,
+	return nil
+}
+
+// autoIncrementField 找到模型的自增字段，校验能否回填
+func (i *Inserter[T]) autoIncrementField(m *model.Model, fields []*model.Field) (*model.Field, error) {
+	if !i.dialect.SupportsLastInsertIdBackfill() || i.onDuplicate != nil {
+		return nil, errs.ErrUnsupportedFillID
 	}
+	for _, fd := range m.Fields {
+		if !fd.AutoIncrement {
+			continue
+		}
+		// 插入时指定了自增列的值，不需要回填
+		for _, c := range fields {
+			if c == fd {
+				return nil, nil
+			}
+		}
+		if !fillable(fd.Typ) {
+			return nil, errs.NewErrUnsupportedAutoIncrementType(fd.GoName, fd.Typ)
+		}
+		return fd, nil
+	}
+	return nil, errs.ErrNoAutoIncrement
+}
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// fillable 整数、整数的指针或者实现了 sql.Scanner 的类型，例如 sql.NullInt64，才能回填 id
+func fillable(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if reflect.PointerTo(typ).Implements(scannerType) {
+		return true
+	}
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+// Returning 执行之后把返回的列按照顺序写回 Values 传入的结构体中，例如数据库生成的 id
+// 不传入列表示返回所有的列，MySQL 不支持
+func (i *Inserter[T]) Returning(cols ...string) *Inserter[T] {
+	i.returning = returning{set: true, cols: cols}
+	return i
 }
 
-func NewInserter[T any](db *DB) *Inserter[T] {
+// execReturning RowsAffected 是返回的行数，不支持 LastInsertId
+func (i *Inserter[T]) execReturning(ctx context.Context, qc *QueryContext) Result {
+	qr := i.core.handle(ctx, qc, func(ctx context.Context, qc *QueryContext) *QueryResult {
+		q, err := qc.Query()
+		if err != nil {
+			return &QueryResult{Err: err}
+		}
+		rows, err := i.sess.queryContext(ctx, q.SQL, q.Args...)
+		if err != nil {
+			return &QueryResult{Err: err}
+		}
+		defer func() { _ = rows.Close() }()
+
+		var affected int64
+		var val valuer.Value
+		for ; rows.Next() && int(affected) < len(i.values); affected++ {
+			if val == nil {
+				val = i.valCreator(i.values[affected], qc.Model)
+			} else {
+				val.Reset(i.values[affected])
+			}
+			if err = val.Scan(rows); err != nil {
+				return &QueryResult{Err: err}
+			}
+		}
+		if err = rows.Err(); err != nil {
+			return &QueryResult{Err: err}
+		}
+		return &QueryResult{Result: driver.RowsAffected(affected)}
+	})
+	return newResult(qr)
+}
+
+func NewInserter[T any](sess Session) *Inserter[T] {
 	return &Inserter[T]{
-		db: db,
+		sess: sess,
 		builder: builder{
-			dialect: db.dialect,
+			core: sess.getCore(),
 		},
 	}
 }
@@ -60,16 +194,22 @@ func (i *Inserter[T]) Build() (*Query, error) {
 		return nil, errs.ErrInsertZeroRow
 	}
 	i.sb.WriteString("INSERT INTO ")
-	m, err := i.db.r.Get(i.values[0])
+	m, err := i.r.Get(i.values[0])
 	if err != nil {
 		return nil, err
 	}
 
 	i.model = m
-	i.quote(m.TableName)
+	i.quoteTable(m)
 
-	// fields 需要插入列的切片，没有设置则表示插入全部的列
-	fields := m.Fields
+	// fields 需要插入列的切片，没有设置则表示插入全部的列，但是跳过自增和只读的列
+	fields := make([]*model.Field, 0, len(m.Fields))
+	for _, fd := range m.Fields {
+		if fd.AutoIncrement || fd.ReadOnly {
+			continue
+		}
+		fields = append(fields, fd)
+	}
 	// 指定了插入的列
 	if len(i.columns) != 0 {
 		fields = make([]*model.Field, 0, len(i.columns))
@@ -81,6 +221,11 @@ func (i *Inserter[T]) Build() (*Query, error) {
 			fields = append(fields, fd)
 		}
 	}
+	if i.fillID {
+		if i.fillField, err = i.autoIncrementField(m, fields); err != nil {
+			return nil, err
+		}
+	}
 	i.sb.WriteByte('(')
 
 	for idx, c := range fields {
@@ -92,7 +237,8 @@ func (i *Inserter[T]) Build() (*Query, error) {
 
 	i.sb.WriteByte(')')
 	i.sb.WriteString(" VALUES")
-	i.args = make([]any, 0, len(i.values)*len(m.Fields))
+	i.rowArgs = len(fields)
+	i.args = make([]any, 0, len(i.values)*len(fields))
 
 	for j, val := range i.values {
 		if j > 0 {
@@ -100,30 +246,35 @@ func (i *Inserter[T]) Build() (*Query, error) {
 		}
 		i.sb.WriteByte('(')
 		//refVal := reflect.ValueOf(val).Elem()
-		refVal := i.db.valCreator(val, i.model)
+		refVal := i.valCreator(val, i.model)
 		// 遍历需要插入的列
 		for idx, c := range fields {
 			if idx > 0 {
 				i.sb.WriteByte(',')
 			}
-			i.sb.WriteByte('?')
-
 			//i.args = append(i.args, refVal.FieldByIndex(c.Index).Interface())
 			fdVal, err := refVal.Field(c.GoName)
 			if err != nil {
 				return nil, err
 			}
-			i.args = append(i.args, fdVal)
+			i.addArg(fdVal)
 		}
 		i.sb.WriteByte(')')
 	}
 
 	if i.onDuplicate != nil {
 		// 构造 ON DUPLICATE KEY 部分
-		err = i.dialect.buildDuplicateKey(&i.builder, i.onDuplicate)
+		spec, err := i.onDuplicate.resolve(m)
 		if err != nil {
 			return nil, err
 		}
+		if err = i.dialect.Upsert(i.writer(), spec); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = i.buildReturning(i.returning); err != nil {
+		return nil, err
 	}
 
 	i.sb.WriteByte(';')
@@ -156,3 +307,45 @@ type Upsert struct {
 	assigns         []Assignable
 	conflictColumns []string
 }
+
+// resolve 把字段名转换为列名，交给 Dialect 构造
+func (u *Upsert) resolve(m *model.Model) (*UpsertSpec, error) {
+	res := &UpsertSpec{
+		ConflictColumns: make([]string, 0, len(u.conflictColumns)),
+		Assigns:         make([]UpsertAssign, 0, len(u.assigns)),
+	}
+	for _, col := range u.conflictColumns {
+		fd, ok := m.FieldMap[col]
+		if !ok {
+			return nil, errs.NewErrUnKnowField(col)
+		}
+		res.ConflictColumns = append(res.ConflictColumns, fd.ColName)
+	}
+	for _, assign := range u.assigns {
+		switch expr := assign.(type) {
+		case Assignment:
+			fd, ok := m.FieldMap[expr.column]
+			if !ok {
+				return nil, errs.NewErrUnKnowField(expr.column)
+			}
+			val := expr.val
+			// JSON 字段的值需要先编码，和 Updater.Set 一样
+			if fd.JSON {
+				var err error
+				if val, err = valuer.EncodeJSON(fd, val); err != nil {
+					return nil, err
+				}
+			}
+			res.Assigns = append(res.Assigns, UpsertAssign{Column: fd.ColName, Val: val})
+		case Column:
+			fd, ok := m.FieldMap[expr.name]
+			if !ok {
+				return nil, errs.NewErrUnKnowField(expr.name)
+			}
+			res.Assigns = append(res.Assigns, UpsertAssign{Column: fd.ColName, UseInserted: true})
+		default:
+			return nil, errs.NewErrUnsupportedExpression(assign)
+		}
+	}
+	return res, nil
+}
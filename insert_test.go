@@ -1,9 +1,14 @@
 package morm
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/soluble1/morm/internal/errs"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reflect"
 	"testing"
 )
 
@@ -120,6 +125,106 @@ func TestInserter_Build(t *testing.T) {
 				Args: []any{int64(12), "xiao", int8(18), &sql.NullString{Valid: true, String: "long"}, 19},
 			},
 		},
+
+		{
+			// JSON 字段更新的值需要编码
+			name: "upsert json",
+			insert: NewInserter[JSONModel](db).Values(&JSONModel{Id: 1, Tags: []string{"a"}}).
+				Columns("Id", "Tags").Upsert().Update(Assign("Tags", []string{"b"}), Assign("Settings", nil)),
+			wantQuery: &Query{
+				SQL: "INSERT INTO `json_model`(`id`,`tags`) VALUES(?,?)" +
+					" ON DUPLICATE KEY UPDATE `tags`=?,`settings`=?;",
+				Args: []any{int64(1), `["a"]`, `["b"]`, nil},
+			},
+		},
+
+		{
+			// 跳过自增、只读和忽略的字段
+			name: "skip fields",
+			insert: NewInserter[TagModel](db).Values(&TagModel{
+				Id:      12,
+				Name:    "xiao",
+				Version: 3,
+				Memo:    "memo",
+			}),
+			wantQuery: &Query{
+				SQL:  "INSERT INTO `tag_model`(`name`) VALUES(?);",
+				Args: []any{"xiao"},
+			},
+		},
+
+		{
+			// 显式指定的时候可以插入自增的字段
+			name: "specify auto increment",
+			insert: NewInserter[TagModel](db).Values(&TagModel{
+				Id:   12,
+				Name: "xiao",
+			}).Columns("Id", "Name"),
+			wantQuery: &Query{
+				SQL:  "INSERT INTO `tag_model`(`id`,`name`) VALUES(?,?);",
+				Args: []any{int64(12), "xiao"},
+			},
+		},
+
+		{
+			name: "specify ignored field",
+			insert: NewInserter[TagModel](db).Values(&TagModel{
+				Memo: "memo",
+			}).Columns("Memo"),
+			wantErr: errs.NewErrUnKnowField("Memo"),
+		},
+
+		// 嵌入的结构体会被展开
+		{
+			name: "embedded",
+			insert: NewInserter[EmbeddedModel](db).Values(&EmbeddedModel{
+				Base: Base{Id: 12, CreateTime: 100},
+				Name: "xiao",
+				Home: Address{City: "sz", Street: "nanshan"},
+			}),
+			wantQuery: &Query{
+				SQL:  "INSERT INTO `embedded_model`(`id`,`create_time`,`name`,`home_city`,`home_street`) VALUES(?,?,?,?,?);",
+				Args: []any{int64(12), int64(100), "xiao", "sz", "nanshan"},
+			},
+		},
+		{
+			name: "embedded twice",
+			insert: NewInserter[TwoAddressModel](db).Values(&TwoAddressModel{
+				Id:   1,
+				Home: Address{City: "sz", Street: "nanshan"},
+				Work: Address{City: "gz", Street: "tianhe"},
+			}),
+			wantQuery: &Query{
+				SQL: "INSERT INTO `two_address_model`(`id`,`home_city`,`home_street`,`work_city`,`work_street`) " +
+					"VALUES(?,?,?,?,?);",
+				Args: []any{int64(1), "sz", "nanshan", "gz", "tianhe"},
+			},
+		},
+		{
+			name: "embedded ptr nil",
+			insert: NewInserter[EmbeddedPtrModel](db).Values(&EmbeddedPtrModel{
+				Name: "xiao",
+			}),
+			wantQuery: &Query{
+				SQL:  "INSERT INTO `embedded_ptr_model`(`name`,`id`,`create_time`) VALUES(?,?,?);",
+				Args: []any{"xiao", int64(0), int64(0)},
+			},
+		},
+
+		// Value 定义在指针上的字段传入指针
+		{
+			name: "valuer",
+			insert: NewInserter[FieldTypeModel](db).Values(&FieldTypeModel{
+				Id:      1,
+				Nick:    sql.NullString{Valid: true, String: "long"},
+				Balance: Amount{Yuan: 12, Fen: 34},
+			}).Columns("Id", "Name", "Nick", "Balance"),
+			wantQuery: &Query{
+				SQL: "INSERT INTO `field_type_model`(`id`,`name`,`nick`,`balance`) VALUES(?,?,?,?);",
+				Args: []any{int64(1), (*string)(nil), sql.NullString{Valid: true, String: "long"},
+					&Amount{Yuan: 12, Fen: 34}},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -132,3 +237,200 @@ func TestInserter_Build(t *testing.T) {
 		})
 	}
 }
+
+// mysqlCompatibleDialect 兼容 MySQL 的第三方方言，例如 TiDB
+type mysqlCompatibleDialect struct {
+	mysqlDialect
+}
+
+func TestInserter_FillAutoIncrementID(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(mock sqlmock.Sqlmock)
+		dialect Dialect
+		i       func(db *DB) *Inserter[TagModel]
+		wantIds []int64
+		wantErr error
+	}{
+		{
+			name: "multiple values",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `tag_model`").WillReturnResult(sqlmock.NewResult(10, 3))
+			},
+			i: func(db *DB) *Inserter[TagModel] {
+				return NewInserter[TagModel](db).FillAutoIncrementID().
+					Values(&TagModel{Name: "a"}, &TagModel{Name: "b"}, &TagModel{Name: "c"})
+			},
+			wantIds: []int64{10, 11, 12},
+		},
+		{
+			// 指定了自增列的值，不回填
+			name: "explicit id",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `tag_model`").WillReturnResult(sqlmock.NewResult(10, 2))
+			},
+			i: func(db *DB) *Inserter[TagModel] {
+				return NewInserter[TagModel](db).FillAutoIncrementID().Columns("Id", "Name").
+					Values(&TagModel{Id: 3, Name: "a"}, &TagModel{Id: 5, Name: "b"})
+			},
+			wantIds: []int64{3, 5},
+		},
+		{
+			name: "exec error",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `tag_model`").WillReturnError(errors.New("exec error"))
+			},
+			i: func(db *DB) *Inserter[TagModel] {
+				return NewInserter[TagModel](db).FillAutoIncrementID().Values(&TagModel{Name: "a"})
+			},
+			wantIds: []int64{0},
+			wantErr: errors.New("exec error"),
+		},
+		{
+			name: "upsert",
+			mock: func(mock sqlmock.Sqlmock) {},
+			i: func(db *DB) *Inserter[TagModel] {
+				return NewInserter[TagModel](db).FillAutoIncrementID().Values(&TagModel{Name: "a"}).
+					Upsert().Update(C("Name"))
+			},
+			wantIds: []int64{0},
+			wantErr: errs.ErrUnsupportedFillID,
+		},
+		{
+			// 组合了 MySQL 的第三方方言
+			name: "mysql compatible",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `tag_model`").WillReturnResult(sqlmock.NewResult(7, 2))
+			},
+			dialect: &mysqlCompatibleDialect{},
+			i: func(db *DB) *Inserter[TagModel] {
+				return NewInserter[TagModel](db).FillAutoIncrementID().
+					Values(&TagModel{Name: "a"}, &TagModel{Name: "b"})
+			},
+			wantIds: []int64{7, 8},
+		},
+		{
+			name:    "sqlite",
+			mock:    func(mock sqlmock.Sqlmock) {},
+			dialect: DialectSQLite,
+			i: func(db *DB) *Inserter[TagModel] {
+				return NewInserter[TagModel](db).FillAutoIncrementID().Values(&TagModel{Name: "a"})
+			},
+			wantIds: []int64{0},
+			wantErr: errs.ErrUnsupportedFillID,
+		},
+	}
+	for _, tt := range tests {
+		for _, opt := range []DBOption{DBUseReflectValuer(), func(db *DB) {}} {
+			t.Run(tt.name, func(t *testing.T) {
+				mockDB, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				defer func() { _ = mockDB.Close() }()
+				tt.mock(mock)
+
+				opts := []DBOption{opt}
+				if tt.dialect != nil {
+					opts = append(opts, DBWithDialect(tt.dialect))
+				}
+				db, err := OpenDB(mockDB, opts...)
+				require.NoError(t, err)
+				i := tt.i(db)
+				_, err = i.Exec(context.Background()).RowsAffected()
+				assert.Equal(t, tt.wantErr, err)
+				ids := make([]int64, 0, len(i.values))
+				for _, val := range i.values {
+					ids = append(ids, val.Id)
+				}
+				assert.Equal(t, tt.wantIds, ids)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			})
+		}
+	}
+}
+
+func TestInserter_FillAutoIncrementIDNoAutoIncrement(t *testing.T) {
+	_, err := NewInserter[TestModel](memoryDB(t)).FillAutoIncrementID().Values(&TestModel{}).Build()
+	assert.Equal(t, errs.ErrNoAutoIncrement, err)
+}
+
+func TestInserter_FillAutoIncrementIDFieldType(t *testing.T) {
+	for _, opt := range []DBOption{DBUseReflectValuer(), func(db *DB) {}} {
+		mockDB, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectExec("INSERT INTO `pointer_id_model`").WillReturnResult(sqlmock.NewResult(10, 2))
+		mock.ExpectExec("INSERT INTO `null_id_model`").WillReturnResult(sqlmock.NewResult(20, 2))
+
+		db, err := OpenDB(mockDB, opt)
+		require.NoError(t, err)
+		ctx := context.Background()
+
+		pointerVals := []*PointerIdModel{{Name: "a"}, {Name: "b"}}
+		_, err = NewInserter[PointerIdModel](db).FillAutoIncrementID().Values(pointerVals...).Exec(ctx).RowsAffected()
+		require.NoError(t, err)
+		require.NotNil(t, pointerVals[0].Id)
+		require.NotNil(t, pointerVals[1].Id)
+		assert.Equal(t, int64(10), *pointerVals[0].Id)
+		assert.Equal(t, int64(11), *pointerVals[1].Id)
+
+		nullVals := []*NullIdModel{{Name: "a"}, {Name: "b"}}
+		_, err = NewInserter[NullIdModel](db).FillAutoIncrementID().Values(nullVals...).Exec(ctx).RowsAffected()
+		require.NoError(t, err)
+		assert.Equal(t, sql.NullInt64{Int64: 20, Valid: true}, nullVals[0].Id)
+		assert.Equal(t, sql.NullInt64{Int64: 21, Valid: true}, nullVals[1].Id)
+
+		// 不能回填的类型在构造的时候就返回错误
+		_, err = NewInserter[StringIdModel](db).FillAutoIncrementID().Values(&StringIdModel{}).Build()
+		assert.Equal(t, errs.NewErrUnsupportedAutoIncrementType("Id", reflect.TypeOf("")), err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		_ = mockDB.Close()
+	}
+}
+
+type PointerIdModel struct {
+	Id   *int64 `orm:"primary_key,auto_increment"`
+	Name string
+}
+
+type NullIdModel struct {
+	Id   sql.NullInt64 `orm:"primary_key,auto_increment"`
+	Name string
+}
+
+type StringIdModel struct {
+	Id   string `orm:"primary_key,auto_increment"`
+	Name string
+}
+
+type TagModel struct {
+	Id      int64 `orm:"primary_key,auto_increment"`
+	Name    string
+	Version int    `orm:"readonly"`
+	Memo    string `orm:"-"`
+}
+
+type Base struct {
+	Id         int64 `orm:"primary_key"`
+	CreateTime int64
+}
+
+type Address struct {
+	City   string
+	Street string
+}
+
+type EmbeddedModel struct {
+	Base
+	Name string
+	Home Address `orm:"embedded,prefix=home_"`
+}
+
+type TwoAddressModel struct {
+	Id   int64
+	Home Address `orm:"embedded,prefix=home_"`
+	Work Address `orm:"embedded,prefix=work_"`
+}
+
+type EmbeddedPtrModel struct {
+	Name string
+	*Base
+}
@@ -6,13 +6,21 @@ import (
 )
 
 var (
-	ErrInputNil           = errors.New("orm: 不支持 nil")
-	ErrPointerOnly        = errors.New("orm: 只支持指针")
-	ErrEmptyTableName     = errors.New("orm: 表名为空")
-	ErrTooManyColumns     = errors.New("orm: 过多列")
-	ErrNoRows             = errors.New("orm: 未找到数据")
-	ErrInsertZeroRow      = errors.New("orm: 插入0行")
-	ErrNonSupportOperator = errors.New("orm: set中不支持的操作")
+	ErrInputNil             = errors.New("orm: 不支持 nil")
+	ErrPointerOnly          = errors.New("orm: 只支持指针")
+	ErrEmptyTableName       = errors.New("orm: 表名为空")
+	ErrTooManyColumns       = errors.New("orm: 过多列")
+	ErrNoRows               = errors.New("orm: 未找到数据")
+	ErrInsertZeroRow        = errors.New("orm: 插入0行")
+	ErrNonSupportOperator   = errors.New("orm: set中不支持的操作")
+	ErrFullTableWrite       = errors.New("orm: 不允许没有 WHERE 的 UPDATE 或者 DELETE")
+	ErrSelectNoLimit        = errors.New("orm: 大表的 SELECT 必须指定 LIMIT")
+	ErrUnsupportedUpsert    = errors.New("orm: 方言不支持 Upsert")
+	ErrNoConflictColumns    = errors.New("orm: Upsert 需要指定冲突的列")
+	ErrUnsupportedReturning = errors.New("orm: 方言不支持 RETURNING")
+	ErrNoAutoIncrement      = errors.New("orm: 模型没有自增主键")
+	ErrNoResult             = errors.New("orm: 没有执行结果，Middleware 没有返回 sql.Result")
+	ErrUnsupportedFillID    = errors.New("orm: 方言不支持回填自增 id 或者使用了 Upsert，请使用 Returning")
 )
 
 func NewErrUnKnowField(name string) error {
@@ -22,3 +30,66 @@ func NewErrUnKnowField(name string) error {
 func NewErrUnKnowColumn(name string) error {
 	return fmt.Errorf("orm: 未知列 %s", name)
 }
+
+func NewErrFailToRollbackTx(bizErr error, rbErr error, panicked bool) error {
+	return fmt.Errorf("orm: 事务闭包回滚失败, 业务错误: %w, 回滚错误: %s, 是否 panic: %t",
+		bizErr, rbErr.Error(), panicked)
+}
+
+func NewErrUnsupportedTable(table any) error {
+	return fmt.Errorf("orm: 不支持的表 %T", table)
+}
+
+func NewErrUnsupportedExpression(expr any) error {
+	return fmt.Errorf("orm: 不支持的表达式 %T", expr)
+}
+
+func NewErrInvalidTagContent(field string, tag string) error {
+	return fmt.Errorf("orm: 字段 %s 的标签 %s 不合法", field, tag)
+}
+
+func NewErrDuplicateField(name string) error {
+	return fmt.Errorf("orm: 重复的字段 %s", name)
+}
+
+func NewErrDuplicateColumn(name string) error {
+	return fmt.Errorf("orm: 重复的列 %s", name)
+}
+
+func NewErrUnsupportedFieldType(field string, typ any) error {
+	return fmt.Errorf("orm: 字段 %s 的类型 %v 不支持扫描，需要实现 sql.Scanner", field, typ)
+}
+
+// NewErrInvalidFieldValue val 不能赋值给字段，例如回填自增 id 的时候字段不是整数
+func NewErrInvalidFieldValue(field string, val any) error {
+	return fmt.Errorf("orm: 不能把 %T 类型的值 %v 赋值给字段 %s", val, val, field)
+}
+
+func NewErrUnsupportedAutoIncrementType(field string, typ any) error {
+	return fmt.Errorf("orm: 自增字段 %s 的类型 %v 不能回填 id，需要是整数、整数指针或者实现 sql.Scanner", field, typ)
+}
+
+// JSONError JSON 列编码或者解码失败，可以通过 errors.As 判断
+type JSONError struct {
+	// Field 字段名，JsonColumn 中为空
+	Field string
+	Err   error
+}
+
+func (e *JSONError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("orm: JSON 不合法: %s", e.Err)
+	}
+	return fmt.Sprintf("orm: 字段 %s 的 JSON 不合法: %s", e.Field, e.Err)
+}
+
+func (e *JSONError) Unwrap() error {
+	return e.Err
+}
+
+func NewErrInvalidJSON(field string, err error) error {
+	return &JSONError{
+		Field: field,
+		Err:   err,
+	}
+}
@@ -0,0 +1,113 @@
+package valuer
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"github.com/soluble1/morm/internal/errs"
+	"github.com/soluble1/morm/model"
+	"reflect"
+)
+
+// fieldByColumn 找到结果集中的列对应的字段
+// 列名可能是 SELECT 中的别名，所以先按列名查找，找不到再按字段名查找
+func fieldByColumn(m *model.Model, col string) (*model.Field, bool) {
+	fd, ok := m.ColumnMap[col]
+	if ok {
+		return fd, true
+	}
+	fd, ok = m.FieldMap[col]
+	return fd, ok
+}
+
+// resolveFields 按照结果集中列的顺序找到对应的字段，一个结果集只需要解析一次
+func resolveFields(rows *sql.Rows, m *model.Model) ([]*model.Field, error) {
+	// Columns 返回查询结果中的所有列名
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	// 校验列数
+	if len(cols) > len(m.ColumnMap) {
+		return nil, errs.ErrTooManyColumns
+	}
+	fields := make([]*model.Field, 0, len(cols))
+	for _, col := range cols {
+		fd, ok := fieldByColumn(m, col)
+		if !ok {
+			return nil, errs.NewErrUnKnowColumn(col)
+		}
+		fields = append(fields, fd)
+	}
+	return fields, nil
+}
+
+// nextRow 没有数据的时候优先返回 rows 中的错误
+func nextRow(rows *sql.Rows) error {
+	if rows.Next() {
+		return nil
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return errs.ErrNoRows
+}
+
+// getStructs 每一行创建一个新的结构体，通过 Reset 复用 v，再追加到 slice 上
+// slice 是 []*T，所以结果集的列只会解析一次
+func getStructs(v Value, slice reflect.Value, rows *sql.Rows) error {
+	elemTyp := slice.Type().Elem().Elem()
+	res := slice
+	for rows.Next() {
+		elem := reflect.New(elemTyp)
+		v.Reset(elem.Interface())
+		if err := v.Scan(rows); err != nil {
+			return err
+		}
+		res = reflect.Append(res, elem)
+	}
+	slice.Set(res)
+	return rows.Err()
+}
+
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// fieldValue 字段的值，作为参数传给驱动
+// JSON 字段编码为 JSON 字符串，Value 方法定义在指针上的时候返回指针，否则驱动不会调用 Value
+func fieldValue(fd *model.Field, fdVal reflect.Value) (any, error) {
+	if fd.JSON {
+		return EncodeJSON(fd, fdVal.Interface())
+	}
+	if !fdVal.Type().Implements(valuerType) && fdVal.CanAddr() &&
+		fdVal.Addr().Type().Implements(valuerType) {
+		return fdVal.Addr().Interface(), nil
+	}
+	return fdVal.Interface(), nil
+}
+
+// setFieldValue 把 val 设置到 fdVal 上，字段是指针的时候分配新的值，
+// 实现了 sql.Scanner 的字段例如 sql.NullInt64 调用 Scan，其它的字段把 val 转换为字段的类型
+func setFieldValue(fd *model.Field, fdVal reflect.Value, val any) error {
+	if fd.Typ.Kind() != reflect.Ptr {
+		return assignValue(fd, fdVal, val)
+	}
+	ptr := reflect.New(fd.Typ.Elem())
+	if err := assignValue(fd, ptr.Elem(), val); err != nil {
+		return err
+	}
+	fdVal.Set(ptr)
+	return nil
+}
+
+func assignValue(fd *model.Field, dst reflect.Value, val any) error {
+	if scanner, ok := dst.Addr().Interface().(sql.Scanner); ok {
+		return scanner.Scan(val)
+	}
+	v := reflect.ValueOf(val)
+	// 整数也可以转换为 string，但是结果是对应的字符
+	if !v.IsValid() || !v.Type().ConvertibleTo(dst.Type()) ||
+		(dst.Kind() == reflect.String && v.Kind() != reflect.String) {
+		return errs.NewErrInvalidFieldValue(fd.GoName, val)
+	}
+	dst.Set(v.Convert(dst.Type()))
+	return nil
+}
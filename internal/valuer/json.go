@@ -0,0 +1,70 @@
+package valuer
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/soluble1/morm/internal/errs"
+	"github.com/soluble1/morm/model"
+	"reflect"
+)
+
+// EncodeJSON 把 JSON 字段的值编码为 JSON 字符串，nil 的 map、slice 和指针编码为 NULL
+func EncodeJSON(fd *model.Field, val any) (any, error) {
+	if val == nil {
+		return nil, nil
+	}
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+	}
+	res, err := json.Marshal(val)
+	if err != nil {
+		return nil, errs.NewErrInvalidJSON(fd.GoName, err)
+	}
+	return string(res), nil
+}
+
+// jsonScanner 先把列扫描为 JSON 字符串，再解码到字段中
+type jsonScanner struct {
+	fd *model.Field
+	// ptr 指向字段的指针
+	ptr reflect.Value
+}
+
+func newJSONScanner(fd *model.Field, ptr reflect.Value) *jsonScanner {
+	return &jsonScanner{
+		fd:  fd,
+		ptr: ptr,
+	}
+}
+
+func (j *jsonScanner) Scan(src any) error {
+	// 先重置为零值，NULL 对应零值
+	j.ptr.Elem().Set(reflect.Zero(j.fd.Typ))
+	var data []byte
+	switch val := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		data = val
+	case string:
+		data = []byte(val)
+	default:
+		return errs.NewErrInvalidJSON(j.fd.GoName, fmt.Errorf("不支持的类型 %T", src))
+	}
+	if err := json.Unmarshal(data, j.ptr.Interface()); err != nil {
+		return errs.NewErrInvalidJSON(j.fd.GoName, err)
+	}
+	return nil
+}
+
+// scanDest 作为 rows.Scan 参数的字段指针，JSON 字段需要先解码
+func scanDest(fd *model.Field, ptr reflect.Value) any {
+	if fd.JSON {
+		return newJSONScanner(fd, ptr)
+	}
+	return ptr.Interface()
+}
@@ -5,11 +5,14 @@ import (
 	"github.com/soluble1/morm/internal/errs"
 	"github.com/soluble1/morm/model"
 	"reflect"
+	"unsafe"
 )
 
 type reflectValue struct {
 	val   reflect.Value
 	model *model.Model
+	// fields 结果集中每一列对应的字段
+	fields []*model.Field
 }
 
 func NewReflectValue(t any, model *model.Model) Value {
@@ -20,62 +23,91 @@ func NewReflectValue(t any, model *model.Model) Value {
 }
 
 func (r *reflectValue) Field(name string) (any, error) {
-	val := r.val
-
 	// 判断一下这个字段存不存在
-	typ := val.Type()
-	_, ok := typ.FieldByName(name)
+	fd, ok := r.model.FieldMap[name]
 	if !ok {
 		return nil, errs.NewErrUnKnowField(name)
 	}
 
-	return val.FieldByName(name).Interface(), nil
+	fdVal, err := r.val.FieldByIndexErr(fd.Index)
+	if err != nil {
+		// 嵌入的结构体指针为 nil，返回零值
+		return fieldValue(fd, reflect.New(fd.Typ).Elem())
+	}
+	return fieldValue(fd, fdVal)
+}
+
+func (r *reflectValue) SetField(name string, val any) error {
+	fd, ok := r.model.FieldMap[name]
+	if !ok {
+		return errs.NewErrUnKnowField(name)
+	}
+	return setFieldValue(fd, fieldByIndex(r.val, fd.Index), val)
+}
+
+// fieldByIndex 和 reflect.Value.FieldByIndex 一样，但是会创建为 nil 的嵌入结构体指针
+func fieldByIndex(val reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && val.Kind() == reflect.Ptr {
+			if val.IsNil() {
+				// 没有导出的嵌入结构体指针不能直接 Set，通过地址重新创建一个可以 Set 的 Value
+				if !val.CanSet() {
+					val = reflect.NewAt(val.Type(), unsafe.Pointer(val.UnsafeAddr())).Elem()
+				}
+				val.Set(reflect.New(val.Type().Elem()))
+			}
+			val = val.Elem()
+		}
+		val = val.Field(x)
+	}
+	return val
 }
 
 func (r *reflectValue) SetColumns(rows *sql.Rows) error {
 	// 没有数据
-	if !rows.Next() {
-		return errs.ErrNoRows
-	}
-	// Columns 返回查询结果中的所有列名
-	cols, err := rows.Columns()
-	if err != nil {
+	if err := nextRow(rows); err != nil {
 		return err
 	}
-	// 校验列数
-	if len(cols) > len(r.model.ColumnMap) {
-		return errs.ErrTooManyColumns
-	}
+	return r.Scan(rows)
+}
 
-	vals := make([]any, 0, len(cols))
-	eleVals := make([]reflect.Value, 0, len(cols))
-	for _, col := range cols {
-		fd, ok := r.model.ColumnMap[col]
-		if !ok {
-			return errs.NewErrUnKnowColumn(col)
+func (r *reflectValue) Scan(rows *sql.Rows) error {
+	if r.fields == nil {
+		fields, err := resolveFields(rows, r.model)
+		if err != nil {
+			return err
 		}
+		r.fields = fields
+	}
+
+	vals := make([]any, 0, len(r.fields))
+	eleVals := make([]reflect.Value, 0, len(r.fields))
+	for _, fd := range r.fields {
 		// 如果 fd.typ 是 int 那么 reflect.New(fd.typ) 是 *int
 		//vals = append(vals, reflect.New(fd.typ).Elem().Interface())
 		fdVal := reflect.New(fd.Typ)
 		eleVals = append(eleVals, fdVal.Elem())
 		// Scan 需要指针不需要调用 Elem
-		vals = append(vals, fdVal.Interface())
+		vals = append(vals, scanDest(fd, fdVal))
 	}
-	err = rows.Scan(vals...)
+	err := rows.Scan(vals...)
 	if err != nil {
 		return err
 	}
 
 	// vals = [123, "long", 18, "xiao"] 将他放到 T 中返回
 	tVal := r.val
-	for i, col := range cols {
-		fd := r.model.ColumnMap[col]
+	for i, fd := range r.fields {
 		//tVal.FieldByName(fd.goName).Set(reflect.ValueOf(vals[i]))
-		tVal.FieldByIndex(fd.Index).Set(eleVals[i])
+		fieldByIndex(tVal, fd.Index).Set(eleVals[i])
 	}
 	return nil
 }
 
+func (r *reflectValue) Reset(t any) {
+	r.val = reflect.ValueOf(t).Elem()
+}
+
 func (r *reflectValue) GetStructs(rows *sql.Rows) error {
-	return nil
+	return getStructs(r, r.val, rows)
 }
@@ -7,12 +7,20 @@ import (
 
 // Value 是对结构体实例的内部抽象
 type Value interface {
-	// SetColumns 设置新值
+	// SetColumns 读取下一行数据并设置新值
 	SetColumns(rows *sql.Rows) error
-	// GetStructs 获取多行数据
+	// Scan 把 rows 当前行的数据设置到结构体上，调用方负责调用 rows.Next
+	// 结果集的列只在第一次调用时解析
+	Scan(rows *sql.Rows) error
+	// Reset 指向一个新的结构体实例，读取多行数据的时候复用同一个 Value
+	Reset(t any)
+	// GetStructs 获取多行数据，创建 Value 时传入的必须是 *[]*T
+	// 调用之后 Value 指向最后一行对应的结构体
 	GetStructs(rows *sql.Rows) error
 
 	Field(name string) (any, error)
+	// SetField 设置字段的值，val 的类型可以转换为字段的类型即可，例如回填自增 id
+	SetField(name string, val any) error
 }
 
 // Creator 简单的factory
@@ -13,6 +13,8 @@ type unsafeValue struct {
 	t     any
 	model *model.Model
 	addr  unsafe.Pointer
+	// fields 结果集中每一列对应的字段
+	fields []*model.Field
 }
 
 func NewUnsafeValue(t any, model *model.Model) Value {
@@ -29,45 +31,81 @@ func (u *unsafeValue) Field(name string) (any, error) {
 	if !ok {
 		return 0, fmt.Errorf("invalid field %s", name)
 	}
-	ptr := unsafe.Pointer(uintptr(u.addr) + fdMeta.Offset)
+	ptr := u.fieldAddr(fdMeta, false)
 	if ptr == nil {
-		return 0, fmt.Errorf("invalid address of the field: %s", name)
+		// 嵌入的结构体指针为 nil，返回零值
+		return fieldValue(fdMeta, reflect.New(fdMeta.Typ).Elem())
 	}
 	// 创建一个新的指向该字段的指针
-	res := reflect.NewAt(fdMeta.Typ, ptr).Elem().Interface()
-	return res, nil
+	return fieldValue(fdMeta, reflect.NewAt(fdMeta.Typ, ptr).Elem())
+}
+
+func (u *unsafeValue) SetField(name string, val any) error {
+	fd, ok := u.model.FieldMap[name]
+	if !ok {
+		return errs.NewErrUnKnowField(name)
+	}
+	return setFieldValue(fd, reflect.NewAt(fd.Typ, u.fieldAddr(fd, true)).Elem(), val)
+}
+
+// fieldAddr 字段的地址，字段在嵌入的结构体指针中时先找到指针指向的结构体
+// 指针为 nil 的时候，alloc 为 true 会创建一个新的结构体，否则返回 nil
+func (u *unsafeValue) fieldAddr(fd *model.Field, alloc bool) unsafe.Pointer {
+	base := u.structAddr(fd.Parent, alloc)
+	if base == nil {
+		return nil
+	}
+	return unsafe.Pointer(uintptr(base) + fd.Offset)
+}
+
+// structAddr parent 指向的结构体的地址，parent 为 nil 表示最外层的结构体
+func (u *unsafeValue) structAddr(parent *model.Field, alloc bool) unsafe.Pointer {
+	if parent == nil {
+		return u.addr
+	}
+	ptr := (*unsafe.Pointer)(u.fieldAddr(parent, alloc))
+	if ptr == nil {
+		return nil
+	}
+	if *ptr == nil && alloc {
+		*ptr = reflect.New(parent.Typ.Elem()).UnsafePointer()
+	}
+	return *ptr
 }
 
 func (u *unsafeValue) SetColumns(rows *sql.Rows) error {
 	// 没有数据
-	if !rows.Next() {
-		return errs.ErrNoRows
-	}
-	cols, err := rows.Columns()
-	if err != nil {
+	if err := nextRow(rows); err != nil {
 		return err
 	}
-	// 校验列数
-	if len(cols) > len(u.model.ColumnMap) {
-		return errs.ErrTooManyColumns
-	}
+	return u.Scan(rows)
+}
 
-	vals := make([]any, 0, len(cols))
-	for _, col := range cols {
-		fd, ok := u.model.ColumnMap[col]
-		if !ok {
-			return errs.NewErrUnKnowColumn(col)
+func (u *unsafeValue) Scan(rows *sql.Rows) error {
+	if u.fields == nil {
+		fields, err := resolveFields(rows, u.model)
+		if err != nil {
+			return err
 		}
+		u.fields = fields
+	}
 
+	vals := make([]any, 0, len(u.fields))
+	for _, fd := range u.fields {
 		// 计算字段的真实地址：对象起始地址 + 字段偏移量
-		fdVal := reflect.NewAt(fd.Typ, unsafe.Pointer(uintptr(u.addr)+fd.Offset))
+		fdVal := reflect.NewAt(fd.Typ, u.fieldAddr(fd, true))
 		// Scan 需要指针不需要调用 Elem
-		vals = append(vals, fdVal.Interface())
+		vals = append(vals, scanDest(fd, fdVal))
 	}
 
 	return rows.Scan(vals...)
 }
 
+func (u *unsafeValue) Reset(t any) {
+	u.t = t
+	u.addr = unsafe.Pointer(reflect.ValueOf(t).Pointer())
+}
+
 func (u *unsafeValue) GetStructs(rows *sql.Rows) error {
-	return nil
+	return getStructs(u, reflect.ValueOf(u.t).Elem(), rows)
 }
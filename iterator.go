@@ -0,0 +1,104 @@
+package morm
+
+import (
+	"context"
+	"database/sql"
+	"github.com/soluble1/morm/internal/valuer"
+	"github.com/soluble1/morm/model"
+)
+
+// Iterator 逐行读取查询结果，内存占用和结果集的大小无关
+// 用法和 sql.Rows 类似：
+//
+//	it := NewSelector[User](db).Iter(ctx)
+//	defer it.Close()
+//	for it.Next() {
+//		u, err := it.Scan()
+//	}
+//	err := it.Err()
+type Iterator[T any] struct {
+	rows  *sql.Rows
+	model *model.Model
+
+	valCreator valuer.Creator
+	// val 所有行复用同一个 Value
+	val valuer.Value
+
+	err error
+}
+
+// Iter 构造 SQL 或者查询出错的时候，错误通过 Err 返回
+func (s *Selector[T]) Iter(ctx context.Context) *Iterator[T] {
+	qc := newQueryContext[T](s.core, "SELECT", s)
+	qc.Streaming = true
+	qr := s.core.handle(ctx, qc, func(ctx context.Context, qc *QueryContext) *QueryResult {
+		q, err := qc.Query()
+		if err != nil {
+			return &QueryResult{Err: err}
+		}
+		rows, err := s.sess.queryContext(ctx, q.SQL, q.Args...)
+		if err != nil {
+			return &QueryResult{Err: err}
+		}
+		return &QueryResult{Result: rows}
+	})
+	rows, err := resultOf[*sql.Rows](qr)
+	if err != nil {
+		// Middleware 返回错误的时候 sql.Rows 不会再被使用
+		if rows != nil {
+			_ = rows.Close()
+		}
+		return &Iterator[T]{err: err}
+	}
+
+	return &Iterator[T]{
+		rows:       rows,
+		model:      s.model,
+		valCreator: s.valCreator,
+	}
+}
+
+// Next 没有数据或者出错的时候返回 false，同时 sql.Rows 会被关闭
+func (it *Iterator[T]) Next() bool {
+	if it.err != nil || it.rows == nil {
+		return false
+	}
+	return it.rows.Next()
+}
+
+// Scan 读取当前行，每次调用都返回一个新的 T
+func (it *Iterator[T]) Scan() (*T, error) {
+	if it.err != nil {
+		return nil, it.err
+	}
+	t := new(T)
+	if it.val == nil {
+		it.val = it.valCreator(t, it.model)
+	} else {
+		it.val.Reset(t)
+	}
+	if err := it.val.Scan(it.rows); err != nil {
+		it.err = err
+		_ = it.rows.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+func (it *Iterator[T]) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	if it.rows == nil {
+		return nil
+	}
+	return it.rows.Err()
+}
+
+// Close 可以重复调用
+func (it *Iterator[T]) Close() error {
+	if it.rows == nil {
+		return nil
+	}
+	return it.rows.Close()
+}
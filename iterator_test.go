@@ -0,0 +1,132 @@
+package morm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/soluble1/morm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSelector_Iter(t *testing.T) {
+	rowErr := errors.New("row error")
+	tests := []struct {
+		name     string
+		s        func(db *DB) *Selector[TestModel]
+		mock     func(mock sqlmock.Sqlmock)
+		wantErr  error
+		wantVals []*TestModel
+	}{
+		{
+			name: "multiple rows",
+			s: func(db *DB) *Selector[TestModel] {
+				return NewSelector[TestModel](db)
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "first_name", "age", "last_name"})
+				rows.AddRow([]byte("1"), []byte("xiao"), []byte("18"), []byte("long"))
+				rows.AddRow([]byte("2"), []byte("ma"), []byte("19"), nil)
+				mock.ExpectQuery("SELECT .*").WillReturnRows(rows).RowsWillBeClosed()
+			},
+			wantVals: []*TestModel{
+				{
+					Id:        1,
+					FirstName: "xiao",
+					Age:       18,
+					LastName:  &sql.NullString{Valid: true, String: "long"},
+				},
+				{
+					Id:        2,
+					FirstName: "ma",
+					Age:       19,
+				},
+			},
+		},
+		{
+			name: "no rows",
+			s: func(db *DB) *Selector[TestModel] {
+				return NewSelector[TestModel](db)
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id"})
+				mock.ExpectQuery("SELECT .*").WillReturnRows(rows).RowsWillBeClosed()
+			},
+		},
+		{
+			name: "build error",
+			s: func(db *DB) *Selector[TestModel] {
+				return NewSelector[TestModel](db).Where(C("age").Eq(1))
+			},
+			mock:    func(mock sqlmock.Sqlmock) {},
+			wantErr: errs.NewErrUnKnowField("age"),
+		},
+		{
+			name: "query error",
+			s: func(db *DB) *Selector[TestModel] {
+				return NewSelector[TestModel](db)
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT .*").WillReturnError(rowErr)
+			},
+			wantErr: rowErr,
+		},
+		{
+			name: "unknown column",
+			s: func(db *DB) *Selector[TestModel] {
+				return NewSelector[TestModel](db)
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "gender"})
+				rows.AddRow([]byte("1"), []byte("man"))
+				mock.ExpectQuery("SELECT .*").WillReturnRows(rows).RowsWillBeClosed()
+			},
+			wantErr: errs.NewErrUnKnowColumn("gender"),
+		},
+		{
+			name: "row error",
+			s: func(db *DB) *Selector[TestModel] {
+				return NewSelector[TestModel](db)
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id"})
+				rows.AddRow([]byte("1"))
+				rows.AddRow([]byte("2"))
+				rows.RowError(1, rowErr)
+				mock.ExpectQuery("SELECT .*").WillReturnRows(rows).RowsWillBeClosed()
+			},
+			wantErr:  rowErr,
+			wantVals: []*TestModel{{Id: 1}},
+		},
+	}
+
+	for _, opt := range []DBOption{DBUseReflectValuer(), func(db *DB) {}} {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockDB, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				defer func() { _ = mockDB.Close() }()
+				tt.mock(mock)
+
+				db, err := OpenDB(mockDB, opt)
+				require.NoError(t, err)
+
+				it := tt.s(db).Iter(context.Background())
+				var vals []*TestModel
+				for it.Next() {
+					val, err := it.Scan()
+					if err != nil {
+						break
+					}
+					vals = append(vals, val)
+				}
+				assert.Equal(t, tt.wantErr, it.Err())
+				assert.Equal(t, tt.wantVals, vals)
+				assert.NoError(t, it.Close())
+				assert.NoError(t, mock.ExpectationsWereMet())
+			})
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package morm
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"github.com/soluble1/morm/internal/errs"
+)
+
+// JsonColumn 以 JSON 格式存储 Val，Valid 为 false 表示 NULL
+type JsonColumn[T any] struct {
+	Val   T
+	Valid bool
+}
+
+func (j JsonColumn[T]) Value() (driver.Value, error) {
+	if !j.Valid {
+		return nil, nil
+	}
+	res, err := json.Marshal(j.Val)
+	if err != nil {
+		return nil, errs.NewErrInvalidJSON("", err)
+	}
+	return string(res), nil
+}
+
+func (j *JsonColumn[T]) Scan(src any) error {
+	var data []byte
+	switch val := src.(type) {
+	case nil:
+		var zero T
+		j.Val, j.Valid = zero, false
+		return nil
+	case []byte:
+		data = val
+	case string:
+		data = []byte(val)
+	default:
+		return errs.NewErrInvalidJSON("", fmt.Errorf("不支持的类型 %T", src))
+	}
+	var val T
+	if err := json.Unmarshal(data, &val); err != nil {
+		return errs.NewErrInvalidJSON("", err)
+	}
+	j.Val, j.Valid = val, true
+	return nil
+}
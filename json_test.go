@@ -0,0 +1,90 @@
+package morm
+
+import (
+	"database/sql/driver"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestJsonColumn_Value(t *testing.T) {
+	tests := []struct {
+		name    string
+		col     JsonColumn[User]
+		wantVal driver.Value
+		wantErr error
+	}{
+		{
+			name:    "valid",
+			col:     JsonColumn[User]{Val: User{Name: "xiao"}, Valid: true},
+			wantVal: `{"Name":"xiao"}`,
+		},
+		{
+			name: "invalid",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			val, err := tt.col.Value()
+			assert.Equal(t, tt.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.wantVal, val)
+		})
+	}
+}
+
+func TestJsonColumn_Scan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     any
+		wantVal JsonColumn[User]
+		wantErr string
+	}{
+		{
+			name:    "bytes",
+			src:     []byte(`{"Name":"xiao"}`),
+			wantVal: JsonColumn[User]{Val: User{Name: "xiao"}, Valid: true},
+		},
+		{
+			name:    "string",
+			src:     `{"Name":"xiao"}`,
+			wantVal: JsonColumn[User]{Val: User{Name: "xiao"}, Valid: true},
+		},
+		{
+			name: "nil",
+		},
+		{
+			name:    "invalid type",
+			src:     123,
+			wantErr: "orm: JSON 不合法: 不支持的类型 int",
+		},
+		{
+			name:    "malformed",
+			src:     `{"Name":`,
+			wantErr: "orm: JSON 不合法: unexpected end of JSON input",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 扫描之前的值会被覆盖
+			col := JsonColumn[User]{Val: User{Name: "old"}, Valid: true}
+			err := col.Scan(tt.src)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.Error())
+				var jsonErr *JSONError
+				assert.True(t, errors.As(err, &jsonErr))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantVal, col)
+		})
+	}
+}
+
+type User struct {
+	Name string
+}
@@ -0,0 +1,118 @@
+package morm
+
+import (
+	"context"
+	"database/sql"
+	"github.com/soluble1/morm/internal/errs"
+	"github.com/soluble1/morm/model"
+)
+
+// QueryContext 一次查询的上下文，在 Middleware 之间传递
+type QueryContext struct {
+	// Type 语句类型，SELECT、INSERT、UPDATE 或者 DELETE
+	Type string
+	// Builder 生成 SQL 的构造器，需要 SQL 的时候使用 Query 而不是直接调用 Build
+	Builder QueryBuilder
+	Model   *model.Model
+	// Streaming 为 true 表示结果是逐行读取的，Handler 返回之后 sql.Rows 还在使用，例如 Iter
+	Streaming bool
+
+	q   *Query
+	err error
+}
+
+// Query 构造 SQL，多次调用只会构造一次
+// 构造器不能重复调用 Build，所以 Middleware 和最终的查询都通过这里拿到 SQL
+func (qc *QueryContext) Query() (*Query, error) {
+	if qc.q == nil && qc.err == nil {
+		qc.q, qc.err = qc.Builder.Build()
+	}
+	return qc.q, qc.err
+}
+
+// FullTableChecker Updater、Deleter 和 Selector 实现了这个接口，Middleware 可以通过 Builder 判断
+type FullTableChecker interface {
+	// IsFullTable UPDATE 和 DELETE 没有 WHERE，或者 SELECT 没有 LIMIT 的时候返回 true
+	// 调用了 AllowFullTable 之后总是返回 false
+	IsFullTable() bool
+}
+
+var (
+	_ FullTableChecker = &Selector[any]{}
+	_ FullTableChecker = &Updater[any]{}
+	_ FullTableChecker = &Deleter[any]{}
+)
+
+// QueryResult 查询的结果，Result 的类型取决于调用的方法：
+// Get 是 *T，GetMulti 是 []*T，Iter 是 *sql.Rows，Exec 是 sql.Result
+type QueryResult struct {
+	Result any
+	Err    error
+}
+
+type Handler func(ctx context.Context, qc *QueryContext) *QueryResult
+
+// Middleware 包装 Handler，可以在查询前后做日志、监控、超时控制之类的事情
+type Middleware func(next Handler) Handler
+
+// DBWithMiddlewares 先传入的 Middleware 在最外层
+func DBWithMiddlewares(mdls ...Middleware) DBOption {
+	return func(db *DB) {
+		db.mdls = append(db.mdls, mdls...)
+	}
+}
+
+// handle 按照 Middleware 注册的顺序包装 root 并执行
+func (c core) handle(ctx context.Context, qc *QueryContext, root Handler) *QueryResult {
+	for i := len(c.mdls) - 1; i >= 0; i-- {
+		root = c.mdls[i](root)
+	}
+	return root(ctx, qc)
+}
+
+// newQueryContext 模型解析失败的时候 Model 为 nil，错误会在构造 SQL 的时候返回
+func newQueryContext[T any](c core, typ string, b QueryBuilder) *QueryContext {
+	m, _ := c.r.Get(new(T))
+	return &QueryContext{
+		Type:    typ,
+		Builder: b,
+		Model:   m,
+	}
+}
+
+// exec INSERT、UPDATE 和 DELETE 的执行
+func exec(ctx context.Context, sess Session, qc *QueryContext) Result {
+	qr := sess.getCore().handle(ctx, qc, func(ctx context.Context, qc *QueryContext) *QueryResult {
+		q, err := qc.Query()
+		if err != nil {
+			return &QueryResult{Err: err}
+		}
+		res, err := sess.execContext(ctx, q.SQL, q.Args...)
+		return &QueryResult{Result: res, Err: err}
+	})
+	return newResult(qr)
+}
+
+// newResult Middleware 没有返回错误也没有返回 sql.Result 的时候返回 ErrNoResult，例如只记录 SQL 的 Middleware
+func newResult(qr *QueryResult) Result {
+	if qr.Err != nil {
+		return Result{err: qr.Err}
+	}
+	res, ok := qr.Result.(sql.Result)
+	if !ok || res == nil {
+		return Result{err: errs.ErrNoResult}
+	}
+	return Result{res: res}
+}
+
+// resultOf 和 newResult 一样，Middleware 没有返回错误也没有返回 R 类型的结果的时候返回 ErrNoResult
+func resultOf[R any](qr *QueryResult) (R, error) {
+	res, ok := qr.Result.(R)
+	if qr.Err != nil {
+		return res, qr.Err
+	}
+	if !ok {
+		return res, errs.ErrNoResult
+	}
+	return res, nil
+}
@@ -0,0 +1,39 @@
+package querylog
+
+import (
+	"context"
+	"github.com/soluble1/morm"
+	"log"
+)
+
+// MiddlewareBuilder 在执行之前输出 SQL 和参数
+type MiddlewareBuilder struct {
+	logFunc func(query string, args []any)
+}
+
+func NewMiddlewareBuilder() *MiddlewareBuilder {
+	return &MiddlewareBuilder{
+		logFunc: func(query string, args []any) {
+			log.Printf("sql: %s, args: %v", query, args)
+		},
+	}
+}
+
+// LogFunc 替换默认的 log.Printf
+func (b *MiddlewareBuilder) LogFunc(fn func(query string, args []any)) *MiddlewareBuilder {
+	b.logFunc = fn
+	return b
+}
+
+func (b *MiddlewareBuilder) Build() morm.Middleware {
+	return func(next morm.Handler) morm.Handler {
+		return func(ctx context.Context, qc *morm.QueryContext) *morm.QueryResult {
+			q, err := qc.Query()
+			// 构造 SQL 失败的错误由后面返回
+			if err == nil {
+				b.logFunc(q.SQL, q.Args)
+			}
+			return next(ctx, qc)
+		}
+	}
+}
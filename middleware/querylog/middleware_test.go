@@ -0,0 +1,40 @@
+package querylog
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/soluble1/morm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	var query string
+	var args []any
+	mdl := NewMiddlewareBuilder().LogFunc(func(q string, as []any) {
+		query, args = q, as
+	}).Build()
+	db, err := morm.OpenDB(mockDB, morm.DBWithMiddlewares(mdl))
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	_, err = morm.NewSelector[TestModel](db).Where(morm.C("Id").Eq(1)).Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM `test_model` WHERE `id` = ?;", query)
+	assert.Equal(t, []any{1}, args)
+
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	_, err = morm.NewInserter[TestModel](db).Values(&TestModel{Id: 1}).Exec(context.Background()).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, "INSERT INTO `test_model`(`id`) VALUES(?);", query)
+	assert.Equal(t, []any{int64(1)}, args)
+}
+
+type TestModel struct {
+	Id int64
+}
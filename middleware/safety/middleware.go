@@ -0,0 +1,42 @@
+package safety
+
+import (
+	"context"
+	"github.com/soluble1/morm"
+)
+
+// MiddlewareBuilder 拒绝没有 WHERE 的 UPDATE 和 DELETE
+// 单个语句可以调用 AllowFullTable 跳过检查
+type MiddlewareBuilder struct {
+	limitLargeTable bool
+}
+
+func NewMiddlewareBuilder() *MiddlewareBuilder {
+	return &MiddlewareBuilder{}
+}
+
+// LimitLargeTable 同时拒绝没有 LIMIT 的 SELECT，只检查通过 model.ModelWithLargeTable 标记的大表
+func (b *MiddlewareBuilder) LimitLargeTable() *MiddlewareBuilder {
+	b.limitLargeTable = true
+	return b
+}
+
+func (b *MiddlewareBuilder) Build() morm.Middleware {
+	return func(next morm.Handler) morm.Handler {
+		return func(ctx context.Context, qc *morm.QueryContext) *morm.QueryResult {
+			checker, ok := qc.Builder.(morm.FullTableChecker)
+			if !ok || !checker.IsFullTable() {
+				return next(ctx, qc)
+			}
+			switch qc.Type {
+			case "UPDATE", "DELETE":
+				return &morm.QueryResult{Err: morm.ErrFullTableWrite}
+			case "SELECT":
+				if b.limitLargeTable && qc.Model != nil && qc.Model.LargeTable {
+					return &morm.QueryResult{Err: morm.ErrSelectNoLimit}
+				}
+			}
+			return next(ctx, qc)
+		}
+	}
+}
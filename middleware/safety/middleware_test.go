@@ -0,0 +1,163 @@
+package safety
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/soluble1/morm"
+	"github.com/soluble1/morm/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	tests := []struct {
+		name            string
+		limitLargeTable bool
+		mock            func(mock sqlmock.Sqlmock)
+		exec            func(db *morm.DB) error
+		wantErr         error
+	}{
+		{
+			name: "delete without where",
+			exec: func(db *morm.DB) error {
+				_, err := morm.NewDeleter[TestModel](db).Exec(context.Background()).RowsAffected()
+				return err
+			},
+			wantErr: morm.ErrFullTableWrite,
+		},
+		{
+			name: "delete with where",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE .* WHERE .*").WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			exec: func(db *morm.DB) error {
+				_, err := morm.NewDeleter[TestModel](db).Where(morm.C("Id").Eq(1)).
+					Exec(context.Background()).RowsAffected()
+				return err
+			},
+		},
+		{
+			name: "delete allow full table",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM `test_model`;").WillReturnResult(sqlmock.NewResult(0, 10))
+			},
+			exec: func(db *morm.DB) error {
+				_, err := morm.NewDeleter[TestModel](db).AllowFullTable().Exec(context.Background()).RowsAffected()
+				return err
+			},
+		},
+		{
+			name: "update without where",
+			exec: func(db *morm.DB) error {
+				_, err := morm.NewUpdater[TestModel](db).Set(morm.C("Id").Eq(1)).
+					Exec(context.Background()).RowsAffected()
+				return err
+			},
+			wantErr: morm.ErrFullTableWrite,
+		},
+		{
+			name: "update allow full table",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE `test_model` SET `id` = \\?;").WillReturnResult(sqlmock.NewResult(0, 10))
+			},
+			exec: func(db *morm.DB) error {
+				_, err := morm.NewUpdater[TestModel](db).Set(morm.C("Id").Eq(1)).AllowFullTable().
+					Exec(context.Background()).RowsAffected()
+				return err
+			},
+		},
+		{
+			name: "select large table without limit check",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			exec: func(db *morm.DB) error {
+				_, err := morm.NewSelector[LargeModel](db).GetMulti(context.Background())
+				return err
+			},
+		},
+		{
+			name:            "select large table without limit",
+			limitLargeTable: true,
+			exec: func(db *morm.DB) error {
+				_, err := morm.NewSelector[LargeModel](db).Where(morm.C("Id").Gt(1)).GetMulti(context.Background())
+				return err
+			},
+			wantErr: morm.ErrSelectNoLimit,
+		},
+		{
+			name:            "iter large table without limit",
+			limitLargeTable: true,
+			exec: func(db *morm.DB) error {
+				return morm.NewSelector[LargeModel](db).Iter(context.Background()).Err()
+			},
+			wantErr: morm.ErrSelectNoLimit,
+		},
+		{
+			name:            "select large table with limit",
+			limitLargeTable: true,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT .* LIMIT \\?;").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			exec: func(db *morm.DB) error {
+				_, err := morm.NewSelector[LargeModel](db).Limit(10).GetMulti(context.Background())
+				return err
+			},
+		},
+		{
+			name:            "select large table allow full table",
+			limitLargeTable: true,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			exec: func(db *morm.DB) error {
+				_, err := morm.NewSelector[LargeModel](db).AllowFullTable().GetMulti(context.Background())
+				return err
+			},
+		},
+		{
+			name:            "select small table without limit",
+			limitLargeTable: true,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			exec: func(db *morm.DB) error {
+				_, err := morm.NewSelector[TestModel](db).GetMulti(context.Background())
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer func() { _ = mockDB.Close() }()
+			if tt.mock != nil {
+				tt.mock(mock)
+			}
+
+			r := model.NewRegistry()
+			_, err = r.Register(&LargeModel{}, model.ModelWithLargeTable())
+			require.NoError(t, err)
+			b := NewMiddlewareBuilder()
+			if tt.limitLargeTable {
+				b = b.LimitLargeTable()
+			}
+			db, err := morm.OpenDB(mockDB, morm.DBWithRegistry(r), morm.DBWithMiddlewares(b.Build()))
+			require.NoError(t, err)
+
+			err = tt.exec(db)
+			assert.Equal(t, tt.wantErr, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+type TestModel struct {
+	Id int64
+}
+
+type LargeModel struct {
+	Id int64
+}
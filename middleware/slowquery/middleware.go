@@ -0,0 +1,48 @@
+package slowquery
+
+import (
+	"context"
+	"github.com/soluble1/morm"
+	"log"
+	"time"
+)
+
+// MiddlewareBuilder 执行时间超过阈值的时候输出 SQL、参数和耗时
+type MiddlewareBuilder struct {
+	threshold time.Duration
+	logFunc   func(query string, args []any, duration time.Duration)
+}
+
+func NewMiddlewareBuilder(threshold time.Duration) *MiddlewareBuilder {
+	return &MiddlewareBuilder{
+		threshold: threshold,
+		logFunc: func(query string, args []any, duration time.Duration) {
+			log.Printf("slow sql: %s, args: %v, duration: %s", query, args, duration)
+		},
+	}
+}
+
+// LogFunc 替换默认的 log.Printf
+func (b *MiddlewareBuilder) LogFunc(fn func(query string, args []any, duration time.Duration)) *MiddlewareBuilder {
+	b.logFunc = fn
+	return b
+}
+
+func (b *MiddlewareBuilder) Build() morm.Middleware {
+	return func(next morm.Handler) morm.Handler {
+		return func(ctx context.Context, qc *morm.QueryContext) *morm.QueryResult {
+			start := time.Now()
+			defer func() {
+				duration := time.Since(start)
+				if duration < b.threshold {
+					return
+				}
+				q, err := qc.Query()
+				if err == nil {
+					b.logFunc(q.SQL, q.Args, duration)
+				}
+			}()
+			return next(ctx, qc)
+		}
+	}
+}
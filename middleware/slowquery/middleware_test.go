@@ -0,0 +1,59 @@
+package slowquery
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/soluble1/morm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	tests := []struct {
+		name     string
+		delay    time.Duration
+		wantSlow bool
+	}{
+		{
+			name:     "slow",
+			delay:    50 * time.Millisecond,
+			wantSlow: true,
+		},
+		{
+			name: "fast",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer func() { _ = mockDB.Close() }()
+
+			var query string
+			var duration time.Duration
+			mdl := NewMiddlewareBuilder(20 * time.Millisecond).
+				LogFunc(func(q string, args []any, d time.Duration) {
+					query, duration = q, d
+				}).Build()
+			db, err := morm.OpenDB(mockDB, morm.DBWithMiddlewares(mdl))
+			require.NoError(t, err)
+
+			mock.ExpectQuery("SELECT .*").WillDelayFor(tt.delay).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			_, err = morm.NewSelector[TestModel](db).Get(context.Background())
+			require.NoError(t, err)
+			if !tt.wantSlow {
+				assert.Equal(t, "", query)
+				return
+			}
+			assert.Equal(t, "SELECT * FROM `test_model`;", query)
+			assert.GreaterOrEqual(t, duration, tt.delay)
+		})
+	}
+}
+
+type TestModel struct {
+	Id int64
+}
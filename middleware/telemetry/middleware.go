@@ -0,0 +1,91 @@
+package telemetry
+
+import (
+	"context"
+	"database/sql"
+	"github.com/soluble1/morm"
+	"time"
+)
+
+// 属性的 key 参考 OpenTelemetry 数据库的语义约定
+const (
+	AttrOperation    = "db.operation"
+	AttrTable        = "db.sql.table"
+	AttrStatement    = "db.statement"
+	AttrRowsAffected = "db.rows_affected"
+
+	// MetricDuration 查询耗时，单位是毫秒
+	MetricDuration = "db.client.duration"
+)
+
+// MiddlewareBuilder 每一次查询创建一个 Span，并且按照表和语句类型记录耗时
+// tracer 或者 meter 为 nil 的时候不记录对应的数据
+type MiddlewareBuilder struct {
+	tracer Tracer
+	meter  Meter
+	redact func(query string) string
+}
+
+func NewMiddlewareBuilder(tracer Tracer, meter Meter) *MiddlewareBuilder {
+	return &MiddlewareBuilder{
+		tracer: tracer,
+		meter:  meter,
+		redact: func(query string) string {
+			return query
+		},
+	}
+}
+
+// RedactSQL 处理 Span 中记录的 SQL，例如去掉 Raw 中的字面量，返回空字符串表示不记录 SQL
+// SQL 中的参数都是占位符，参数本身不会被记录
+func (b *MiddlewareBuilder) RedactSQL(fn func(query string) string) *MiddlewareBuilder {
+	b.redact = fn
+	return b
+}
+
+func (b *MiddlewareBuilder) Build() morm.Middleware {
+	var histogram Histogram
+	if b.meter != nil {
+		histogram = b.meter.Histogram(MetricDuration)
+	}
+	return func(next morm.Handler) morm.Handler {
+		return func(ctx context.Context, qc *morm.QueryContext) *morm.QueryResult {
+			var table string
+			if qc.Model != nil {
+				table = qc.Model.TableName
+			}
+			attrs := []Attribute{String(AttrOperation, qc.Type), String(AttrTable, table)}
+
+			start := time.Now()
+			if histogram != nil {
+				defer func() {
+					histogram.Record(ctx, float64(time.Since(start).Microseconds())/1000, attrs...)
+				}()
+			}
+			if b.tracer == nil {
+				return next(ctx, qc)
+			}
+
+			ctx, span := b.tracer.Start(ctx, qc.Type+" "+table)
+			defer span.End()
+			span.SetAttributes(attrs...)
+			if q, err := qc.Query(); err == nil {
+				if statement := b.redact(q.SQL); statement != "" {
+					span.SetAttributes(String(AttrStatement, statement))
+				}
+			}
+
+			res := next(ctx, qc)
+			if res.Err != nil {
+				span.RecordError(res.Err)
+				return res
+			}
+			if r, ok := res.Result.(sql.Result); ok {
+				if affected, err := r.RowsAffected(); err == nil {
+					span.SetAttributes(Int64(AttrRowsAffected, affected))
+				}
+			}
+			return res
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/soluble1/morm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	queryErr := errors.New("query error")
+	tests := []struct {
+		name   string
+		redact func(query string) string
+		mock   func(mock sqlmock.Sqlmock)
+		exec   func(db *morm.DB) error
+
+		wantSpan   *memorySpan
+		wantMetric []Attribute
+	}{
+		{
+			name: "select",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			},
+			exec: func(db *morm.DB) error {
+				_, err := morm.NewSelector[TestModel](db).Where(morm.C("Id").Eq(1)).Get(context.Background())
+				return err
+			},
+			wantSpan: &memorySpan{
+				name: "SELECT test_model",
+				attrs: []Attribute{
+					String(AttrOperation, "SELECT"),
+					String(AttrTable, "test_model"),
+					String(AttrStatement, "SELECT * FROM `test_model` WHERE `id` = ?;"),
+				},
+				ended: true,
+			},
+			wantMetric: []Attribute{String(AttrOperation, "SELECT"), String(AttrTable, "test_model")},
+		},
+		{
+			name: "update rows affected",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE .*").WillReturnResult(sqlmock.NewResult(0, 3))
+			},
+			exec: func(db *morm.DB) error {
+				_, err := morm.NewUpdater[TestModel](db).Set(morm.C("Id").Eq(1)).
+					Exec(context.Background()).RowsAffected()
+				return err
+			},
+			wantSpan: &memorySpan{
+				name: "UPDATE test_model",
+				attrs: []Attribute{
+					String(AttrOperation, "UPDATE"),
+					String(AttrTable, "test_model"),
+					String(AttrStatement, "UPDATE `test_model` SET `id` = ?;"),
+					Int64(AttrRowsAffected, 3),
+				},
+				ended: true,
+			},
+			wantMetric: []Attribute{String(AttrOperation, "UPDATE"), String(AttrTable, "test_model")},
+		},
+		{
+			name: "redact",
+			redact: func(query string) string {
+				return strings.ReplaceAll(query, "'xiao'", "?")
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE .*").WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			exec: func(db *morm.DB) error {
+				_, err := morm.NewDeleter[TestModel](db).Where(morm.Raw("`name` = 'xiao'").AsPredicate()).
+					Exec(context.Background()).RowsAffected()
+				return err
+			},
+			wantSpan: &memorySpan{
+				name: "DELETE test_model",
+				attrs: []Attribute{
+					String(AttrOperation, "DELETE"),
+					String(AttrTable, "test_model"),
+					String(AttrStatement, "DELETE FROM `test_model` WHERE `name` = ?;"),
+					Int64(AttrRowsAffected, 1),
+				},
+				ended: true,
+			},
+			wantMetric: []Attribute{String(AttrOperation, "DELETE"), String(AttrTable, "test_model")},
+		},
+		{
+			name: "omit statement",
+			redact: func(query string) string {
+				return ""
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT .*").WillReturnError(queryErr)
+			},
+			exec: func(db *morm.DB) error {
+				_, err := morm.NewSelector[TestModel](db).GetMulti(context.Background())
+				return err
+			},
+			wantSpan: &memorySpan{
+				name: "SELECT test_model",
+				attrs: []Attribute{
+					String(AttrOperation, "SELECT"),
+					String(AttrTable, "test_model"),
+				},
+				errs:  []error{queryErr},
+				ended: true,
+			},
+			wantMetric: []Attribute{String(AttrOperation, "SELECT"), String(AttrTable, "test_model")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer func() { _ = mockDB.Close() }()
+			tt.mock(mock)
+
+			tracer, meter := &memoryTracer{}, &memoryMeter{}
+			b := NewMiddlewareBuilder(tracer, meter)
+			if tt.redact != nil {
+				b = b.RedactSQL(tt.redact)
+			}
+			db, err := morm.OpenDB(mockDB, morm.DBWithMiddlewares(b.Build()))
+			require.NoError(t, err)
+			_ = tt.exec(db)
+
+			require.Len(t, tracer.spans, 1)
+			assert.Equal(t, tt.wantSpan, tracer.spans[0])
+			require.Len(t, meter.histograms[MetricDuration].records, 1)
+			assert.Equal(t, tt.wantMetric, meter.histograms[MetricDuration].records[0].attrs)
+			assert.GreaterOrEqual(t, meter.histograms[MetricDuration].records[0].val, float64(0))
+		})
+	}
+}
+
+func TestMiddlewareBuilder_NilTracerAndMeter(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	db, err := morm.OpenDB(mockDB, morm.DBWithMiddlewares(NewMiddlewareBuilder(nil, nil).Build()))
+	require.NoError(t, err)
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	res, err := morm.NewSelector[TestModel](db).Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &TestModel{Id: 1}, res)
+}
+
+type TestModel struct {
+	Id int64
+}
+
+// memoryTracer 在内存中记录 Span
+type memoryTracer struct {
+	spans []*memorySpan
+}
+
+func (m *memoryTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &memorySpan{name: name}
+	m.spans = append(m.spans, span)
+	return ctx, span
+}
+
+type memorySpan struct {
+	name  string
+	attrs []Attribute
+	errs  []error
+	ended bool
+}
+
+func (m *memorySpan) SetAttributes(attrs ...Attribute) {
+	m.attrs = append(m.attrs, attrs...)
+}
+
+func (m *memorySpan) RecordError(err error) {
+	m.errs = append(m.errs, err)
+}
+
+func (m *memorySpan) End() {
+	m.ended = true
+}
+
+type memoryMeter struct {
+	histograms map[string]*memoryHistogram
+}
+
+func (m *memoryMeter) Histogram(name string) Histogram {
+	if m.histograms == nil {
+		m.histograms = make(map[string]*memoryHistogram)
+	}
+	res := &memoryHistogram{}
+	m.histograms[name] = res
+	return res
+}
+
+type memoryHistogram struct {
+	records []record
+}
+
+type record struct {
+	val   float64
+	attrs []Attribute
+}
+
+func (m *memoryHistogram) Record(ctx context.Context, val float64, attrs ...Attribute) {
+	m.records = append(m.records, record{val: val, attrs: attrs})
+}
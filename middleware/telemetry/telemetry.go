@@ -0,0 +1,37 @@
+package telemetry
+
+import "context"
+
+// Tracer 创建 Span，使用 OpenTelemetry 的时候包装一下 trace.Tracer 就可以
+// morm 不依赖具体的 SDK
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Meter 按照名字创建 Histogram
+type Meter interface {
+	Histogram(name string) Histogram
+}
+
+type Histogram interface {
+	Record(ctx context.Context, val float64, attrs ...Attribute)
+}
+
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+func String(key string, val string) Attribute {
+	return Attribute{Key: key, Value: val}
+}
+
+func Int64(key string, val int64) Attribute {
+	return Attribute{Key: key, Value: val}
+}
@@ -0,0 +1,33 @@
+package timeout
+
+import (
+	"context"
+	"github.com/soluble1/morm"
+	"time"
+)
+
+// MiddlewareBuilder 给每一次查询设置超时时间
+// ctx 中已经有更早的 deadline 的时候以 ctx 为准
+type MiddlewareBuilder struct {
+	timeout time.Duration
+}
+
+func NewMiddlewareBuilder(timeout time.Duration) *MiddlewareBuilder {
+	return &MiddlewareBuilder{
+		timeout: timeout,
+	}
+}
+
+func (b *MiddlewareBuilder) Build() morm.Middleware {
+	return func(next morm.Handler) morm.Handler {
+		return func(ctx context.Context, qc *morm.QueryContext) *morm.QueryResult {
+			// 逐行读取的时候 Handler 返回之后还要使用 sql.Rows，取消 ctx 会导致 sql.Rows 被关闭
+			if qc.Streaming {
+				return next(ctx, qc)
+			}
+			ctx, cancel := context.WithTimeout(ctx, b.timeout)
+			defer cancel()
+			return next(ctx, qc)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package timeout
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/soluble1/morm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	mdl := NewMiddlewareBuilder(20 * time.Millisecond).Build()
+	db, err := morm.OpenDB(mockDB, morm.DBWithMiddlewares(mdl))
+	require.NoError(t, err)
+
+	// 超时，sqlmock 在 ctx 结束的时候返回自己的错误
+	mock.ExpectExec("DELETE .*").WillDelayFor(100 * time.Millisecond).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	start := time.Now()
+	_, err = morm.NewDeleter[TestModel](db).Exec(context.Background()).RowsAffected()
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	// 没有超时
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	res, err := morm.NewSelector[TestModel](db).Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &TestModel{Id: 1}, res)
+}
+
+func TestMiddlewareBuilder_Streaming(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	mdl := NewMiddlewareBuilder(20 * time.Millisecond).Build()
+	db, err := morm.OpenDB(mockDB, morm.DBWithMiddlewares(mdl))
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	it := morm.NewSelector[TestModel](db).Iter(context.Background())
+	defer func() { _ = it.Close() }()
+	// 超过超时时间之后仍然可以读取
+	time.Sleep(50 * time.Millisecond)
+	var ids []int64
+	for it.Next() {
+		val, err := it.Scan()
+		require.NoError(t, err)
+		ids = append(ids, val.Id)
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, []int64{1, 2}, ids)
+}
+
+type TestModel struct {
+	Id int64
+}
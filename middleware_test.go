@@ -0,0 +1,153 @@
+package morm
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/soluble1/morm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	var logs []string
+	mdl := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, qc *QueryContext) *QueryResult {
+				q, err := qc.Query()
+				require.NoError(t, err)
+				logs = append(logs, name+" "+qc.Type+" "+qc.Model.TableName+" "+q.SQL)
+				res := next(ctx, qc)
+				logs = append(logs, name+" after")
+				return res
+			}
+		}
+	}
+	db, err := OpenDB(mockDB, DBWithMiddlewares(mdl("first"), mdl("second")))
+	require.NoError(t, err)
+
+	// 多个 Middleware 调用 Query，SQL 只会构造一次
+	mock.ExpectQuery("SELECT \\* FROM `test_model` WHERE `id` = \\?;").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	res, err := NewSelector[TestModel](db).Where(C("Id").Eq(1)).Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &TestModel{Id: 1}, res)
+	assert.Equal(t, []string{
+		"first SELECT test_model SELECT * FROM `test_model` WHERE `id` = ?;",
+		"second SELECT test_model SELECT * FROM `test_model` WHERE `id` = ?;",
+		"second after",
+		"first after",
+	}, logs)
+
+	logs = nil
+	mock.ExpectExec("DELETE FROM `test_model`;").WillReturnResult(sqlmock.NewResult(0, 3))
+	affected, err := NewDeleter[TestModel](db).Exec(context.Background()).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), affected)
+	assert.Equal(t, []string{
+		"first DELETE test_model DELETE FROM `test_model`;",
+		"second DELETE test_model DELETE FROM `test_model`;",
+		"second after",
+		"first after",
+	}, logs)
+
+	// 事务中同样会经过 Middleware
+	logs = nil
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `test_model` SET `age` = \\?;").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	err = db.DoTx(context.Background(), func(ctx context.Context, tx *Tx) error {
+		_, err := NewUpdater[TestModel](tx).Set(C("Age").Eq(18)).Exec(ctx).RowsAffected()
+		return err
+	}, nil)
+	require.NoError(t, err)
+	assert.Len(t, logs, 4)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMiddleware_ShortCircuit(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	mdlErr := errors.New("mdl error")
+	db, err := OpenDB(mockDB, DBWithMiddlewares(func(next Handler) Handler {
+		return func(ctx context.Context, qc *QueryContext) *QueryResult {
+			switch qc.Type {
+			case "SELECT":
+				return &QueryResult{Err: mdlErr}
+			case "DELETE":
+				// 没有结果也没有错误
+				return &QueryResult{}
+			}
+			return &QueryResult{Result: sqlmock.NewResult(12, 1)}
+		}
+	}))
+	require.NoError(t, err)
+
+	_, err = NewSelector[TestModel](db).Get(context.Background())
+	assert.Equal(t, mdlErr, err)
+	_, err = NewSelector[TestModel](db).GetMulti(context.Background())
+	assert.Equal(t, mdlErr, err)
+	it := NewSelector[TestModel](db).Iter(context.Background())
+	assert.False(t, it.Next())
+	assert.Equal(t, mdlErr, it.Err())
+
+	id, err := NewInserter[TestModel](db).Values(&TestModel{}).Exec(context.Background()).LastInsertId()
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), id)
+
+	res := NewDeleter[TestModel](db).Where(C("Id").Eq(1)).Exec(context.Background())
+	_, err = res.RowsAffected()
+	assert.Equal(t, ErrNoResult, err)
+	_, err = res.LastInsertId()
+	assert.Equal(t, ErrNoResult, err)
+	_, err = NewDeleter[TestModel](db).Where(C("Id").Eq(1)).Returning().Exec(context.Background()).RowsAffected()
+	assert.Equal(t, ErrNoResult, err)
+
+	// 没有结果也没有错误，读取数据的方法同样返回 ErrNoResult
+	emptyDB, err := OpenDB(mockDB, DBWithMiddlewares(func(next Handler) Handler {
+		return func(ctx context.Context, qc *QueryContext) *QueryResult {
+			return &QueryResult{}
+		}
+	}))
+	require.NoError(t, err)
+	got, err := NewSelector[TestModel](emptyDB).Get(context.Background())
+	assert.Equal(t, ErrNoResult, err)
+	assert.Nil(t, got)
+	_, err = NewSelector[TestModel](emptyDB).GetMulti(context.Background())
+	assert.Equal(t, ErrNoResult, err)
+	it = NewSelector[TestModel](emptyDB).Iter(context.Background())
+	assert.False(t, it.Next())
+	assert.Equal(t, ErrNoResult, it.Err())
+	_, err = NewUpdater[TestModel](emptyDB).Set(C("Age").Eq(1)).Where(C("Id").Eq(1)).GetMulti(context.Background())
+	assert.Equal(t, ErrNoResult, err)
+	_, err = NewDeleter[TestModel](emptyDB).Where(C("Id").Eq(1)).GetMulti(context.Background())
+	assert.Equal(t, ErrNoResult, err)
+
+	// 没有真正执行查询
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMiddleware_BuildError(t *testing.T) {
+	mockDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	called := false
+	db, err := OpenDB(mockDB, DBWithMiddlewares(func(next Handler) Handler {
+		return func(ctx context.Context, qc *QueryContext) *QueryResult {
+			called = true
+			return next(ctx, qc)
+		}
+	}))
+	require.NoError(t, err)
+	_, err = NewSelector[TestModel](db).Where(C("Invalid").Eq(1)).Get(context.Background())
+	assert.True(t, called)
+	assert.Equal(t, errs.NewErrUnKnowField("Invalid"), err)
+}
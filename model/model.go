@@ -10,6 +10,10 @@ type ModelOpt func(m *Model) error
 type Model struct {
 	// 结构体对应的表名
 	TableName string
+	// 表所在的 schema，为空表示使用连接默认的 schema
+	Schema string
+	// LargeTable 为 true 表示数据量很大，safety 之类的 Middleware 会要求 SELECT 带上 LIMIT
+	LargeTable bool
 	// 字段名对应的列名
 	FieldMap map[string]*Field
 
@@ -17,6 +21,9 @@ type Model struct {
 	ColumnMap map[string]*Field
 
 	Fields []*Field
+
+	// 主键，按照字段定义的顺序排列
+	PrimaryKeys []*Field
 }
 
 func ModelWithTableName(name string) ModelOpt {
@@ -29,13 +36,37 @@ func ModelWithTableName(name string) ModelOpt {
 	}
 }
 
+// ModelWithSchema 生成的 SQL 中表名会带上 schema，例如 `analytics`.`user`
+func ModelWithSchema(schema string) ModelOpt {
+	return func(m *Model) error {
+		m.Schema = schema
+		return nil
+	}
+}
+
+// ModelWithLargeTable 标记为大表
+func ModelWithLargeTable() ModelOpt {
+	return func(m *Model) error {
+		m.LargeTable = true
+		return nil
+	}
+}
+
 func ModelWithColumnName(field string, colName string) ModelOpt {
 	return func(m *Model) error {
 		fd, ok := m.FieldMap[field]
 		if !ok {
 			return errs.NewErrUnKnowField(field)
 		}
+		if colName == fd.ColName {
+			return nil
+		}
+		if _, ok = m.ColumnMap[colName]; ok {
+			return errs.NewErrDuplicateColumn(colName)
+		}
+		delete(m.ColumnMap, fd.ColName)
 		fd.ColName = colName
+		m.ColumnMap[colName] = fd
 		return nil
 	}
 }
@@ -48,17 +79,37 @@ func ModelWithColumn(field string, col *Field) ModelOpt {
 }
 
 type Field struct {
-	// 字段名
+	// 字段名，具名的嵌入结构体中的字段带上结构体的字段名，例如 Home.City
 	GoName string
 	// 字段对应的列名
 	ColName string
 
-	// 字段偏移量
+	// 字段偏移量，字段在嵌入的结构体指针中时，是相对于指针指向的结构体的偏移量
 	Offset uintptr
 
 	Typ reflect.Type
 
+	// 从最外层结构体开始的下标路径，嵌入的结构体会被展开
 	Index []int
+
+	// JSON 为 true 表示字段以 JSON 格式存储
+	JSON bool
+
+	// Parent 字段所在的嵌入结构体指针，为 nil 表示可以直接通过 Offset 访问
+	Parent *Field
+
+	// 下面的部分来自 orm 标签，例如 `orm:"column=id,primary_key,auto_increment"`
+	PrimaryKey    bool
+	AutoIncrement bool
+	// Default 列的默认值，原样记录
+	Default  string
+	Nullable bool
+	Size     int
+	// SQLType 列在数据库中的类型，例如 varchar(128)
+	SQLType string
+	// ReadOnly 只读的列，插入的时候跳过
+	ReadOnly bool
+	Unique   bool
 }
 
 type TableName interface {
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"github.com/soluble1/morm/internal/errs"
 	"github.com/stretchr/testify/assert"
+	"reflect"
 	"testing"
 )
 
@@ -12,26 +13,44 @@ func Test_parseModel(t *testing.T) {
 		name      string
 		input     any
 		wantModel *Model
-		wantErr   error
+		// fields 按照定义的顺序排列，用来构造 wantModel 的 FieldMap、ColumnMap 和 Fields
+		fields  []*Field
+		wantErr error
 	}{
 		{
 			name:  "ptr",
 			input: &TestModel{},
 			wantModel: &Model{
 				TableName: "test_model",
-				FieldMap: map[string]*Field{
-					"Id": {
-						ColName: "id",
-					},
-					"FirstName": {
-						ColName: "first_name",
-					},
-					"Age": {
-						ColName: "age",
-					},
-					"LastName": {
-						ColName: "last_name",
-					},
+			},
+			fields: []*Field{
+				{
+					GoName:  "Id",
+					ColName: "id",
+					Typ:     reflect.TypeOf(int64(0)),
+					Offset:  0,
+					Index:   []int{0},
+				},
+				{
+					GoName:  "FirstName",
+					ColName: "first_name",
+					Typ:     reflect.TypeOf(""),
+					Offset:  8,
+					Index:   []int{1},
+				},
+				{
+					GoName:  "Age",
+					ColName: "age",
+					Typ:     reflect.TypeOf(int8(0)),
+					Offset:  24,
+					Index:   []int{2},
+				},
+				{
+					GoName:  "LastName",
+					ColName: "last_name",
+					Typ:     reflect.TypeOf(&sql.NullString{}),
+					Offset:  32,
+					Index:   []int{3},
 				},
 			},
 		},
@@ -61,31 +80,557 @@ func Test_parseModel(t *testing.T) {
 			}(),
 			wantModel: &Model{
 				TableName: "column_tag",
-				FieldMap: map[string]*Field{
-					// 默认是 i_d
-					"ID": {
-						ColName: "id",
+			},
+			fields: []*Field{
+				{
+					GoName:  "ID",
+					ColName: "id",
+					Typ:     reflect.TypeOf(uint64(0)),
+					Index:   []int{0},
+				},
+			},
+		},
+		{
+			name: "full tags",
+			input: func() any {
+				type FullTag struct {
+					Id       int64  `orm:"column=user_id,primary_key,auto_increment"`
+					Name     string `orm:"size=128,type=varchar(128),unique"`
+					Nickname string `orm:"default=anonymous, nullable"`
+					Score    int    `orm:"readonly"`
+					Ignored  string `orm:"-"`
+				}
+				return &FullTag{}
+			}(),
+			wantModel: &Model{
+				TableName: "full_tag",
+			},
+			fields: []*Field{
+				{
+					GoName:        "Id",
+					ColName:       "user_id",
+					Typ:           reflect.TypeOf(int64(0)),
+					Index:         []int{0},
+					PrimaryKey:    true,
+					AutoIncrement: true,
+				},
+				{
+					GoName:  "Name",
+					ColName: "name",
+					Typ:     reflect.TypeOf(""),
+					Offset:  8,
+					Index:   []int{1},
+					Size:    128,
+					SQLType: "varchar(128)",
+					Unique:  true,
+				},
+				{
+					GoName:   "Nickname",
+					ColName:  "nickname",
+					Typ:      reflect.TypeOf(""),
+					Offset:   24,
+					Index:    []int{2},
+					Default:  "anonymous",
+					Nullable: true,
+				},
+				{
+					GoName:   "Score",
+					ColName:  "score",
+					Typ:      reflect.TypeOf(0),
+					Offset:   40,
+					Index:    []int{3},
+					ReadOnly: true,
+				},
+			},
+		},
+		{
+			name: "composite primary key",
+			input: func() any {
+				type CompositeKey struct {
+					UserId  int64 `orm:"primary_key"`
+					GroupId int64 `orm:"primary_key"`
+				}
+				return &CompositeKey{}
+			}(),
+			wantModel: &Model{
+				TableName: "composite_key",
+			},
+			fields: []*Field{
+				{
+					GoName:     "UserId",
+					ColName:    "user_id",
+					Typ:        reflect.TypeOf(int64(0)),
+					Index:      []int{0},
+					PrimaryKey: true,
+				},
+				{
+					GoName:     "GroupId",
+					ColName:    "group_id",
+					Typ:        reflect.TypeOf(int64(0)),
+					Offset:     8,
+					Index:      []int{1},
+					PrimaryKey: true,
+				},
+			},
+		},
+		{
+			name: "invalid size",
+			input: func() any {
+				type InvalidSize struct {
+					Name string `orm:"size=abc"`
+				}
+				return &InvalidSize{}
+			}(),
+			wantErr: errs.NewErrInvalidTagContent("Name", "size=abc"),
+		},
+		{
+			name: "unknown tag",
+			input: func() any {
+				type UnknownTag struct {
+					Name string `orm:"colum=name"`
+				}
+				return &UnknownTag{}
+			}(),
+			wantErr: errs.NewErrInvalidTagContent("Name", "colum=name"),
+		},
+		{
+			name: "flag with value",
+			input: func() any {
+				type FlagWithValue struct {
+					Id int64 `orm:"primary_key=true"`
+				}
+				return &FlagWithValue{}
+			}(),
+			wantErr: errs.NewErrInvalidTagContent("Id", "primary_key=true"),
+		},
+		{
+			name: "embedded",
+			input: func() any {
+				type EmbeddedModel struct {
+					BaseModel
+					Name string
+				}
+				return &EmbeddedModel{}
+			}(),
+			wantModel: &Model{
+				TableName: "embedded_model",
+			},
+			fields: []*Field{
+				{
+					GoName:     "Id",
+					ColName:    "id",
+					Typ:        reflect.TypeOf(int64(0)),
+					Index:      []int{0, 0},
+					PrimaryKey: true,
+				},
+				{
+					GoName:  "CreateTime",
+					ColName: "create_time",
+					Typ:     reflect.TypeOf(int64(0)),
+					Offset:  8,
+					Index:   []int{0, 1},
+				},
+				{
+					GoName:  "Name",
+					ColName: "name",
+					Typ:     reflect.TypeOf(""),
+					Offset:  16,
+					Index:   []int{1},
+				},
+			},
+		},
+		{
+			name: "embedded ptr",
+			input: func() any {
+				type EmbeddedPtrModel struct {
+					Name string
+					*BaseModel
+				}
+				return &EmbeddedPtrModel{}
+			}(),
+			wantModel: &Model{
+				TableName: "embedded_ptr_model",
+			},
+			fields: func() []*Field {
+				parent := &Field{
+					GoName: "BaseModel",
+					Typ:    reflect.TypeOf(&BaseModel{}),
+					Offset: 16,
+					Index:  []int{1},
+				}
+				return []*Field{
+					{
+						GoName:  "Name",
+						ColName: "name",
+						Typ:     reflect.TypeOf(""),
+						Index:   []int{0},
+					},
+					{
+						GoName:     "Id",
+						ColName:    "id",
+						Typ:        reflect.TypeOf(int64(0)),
+						Index:      []int{1, 0},
+						PrimaryKey: true,
+						Parent:     parent,
 					},
+					{
+						GoName:  "CreateTime",
+						ColName: "create_time",
+						Typ:     reflect.TypeOf(int64(0)),
+						Offset:  8,
+						Index:   []int{1, 1},
+						Parent:  parent,
+					},
+				}
+			}(),
+		},
+		{
+			name: "embedded with prefix",
+			input: func() any {
+				type Address struct {
+					City   string
+					Street string
+				}
+				type PrefixModel struct {
+					Id   int64
+					Home Address `orm:"embedded,prefix=home_"`
+				}
+				return &PrefixModel{}
+			}(),
+			wantModel: &Model{
+				TableName: "prefix_model",
+			},
+			fields: []*Field{
+				{
+					GoName:  "Id",
+					ColName: "id",
+					Typ:     reflect.TypeOf(int64(0)),
+					Index:   []int{0},
+				},
+				{
+					GoName:  "Home.City",
+					ColName: "home_city",
+					Typ:     reflect.TypeOf(""),
+					Offset:  8,
+					Index:   []int{1, 0},
+				},
+				{
+					GoName:  "Home.Street",
+					ColName: "home_street",
+					Typ:     reflect.TypeOf(""),
+					Offset:  24,
+					Index:   []int{1, 1},
+				},
+			},
+		},
+		{
+			// 同一个结构体嵌入两次，字段名使用 Home.City 和 Work.City 区分
+			name: "embedded twice with prefix",
+			input: func() any {
+				type Address struct {
+					City string
+				}
+				type TwoAddressModel struct {
+					Home Address `orm:"embedded,prefix=home_"`
+					Work Address `orm:"embedded,prefix=work_"`
+				}
+				return &TwoAddressModel{}
+			}(),
+			wantModel: &Model{
+				TableName: "two_address_model",
+			},
+			fields: []*Field{
+				{
+					GoName:  "Home.City",
+					ColName: "home_city",
+					Typ:     reflect.TypeOf(""),
+					Index:   []int{0, 0},
+				},
+				{
+					GoName:  "Work.City",
+					ColName: "work_city",
+					Typ:     reflect.TypeOf(""),
+					Offset:  16,
+					Index:   []int{1, 0},
+				},
+			},
+		},
+		{
+			name: "embedded scanner",
+			input: func() any {
+				type ScannerModel struct {
+					sql.NullString
+				}
+				return &ScannerModel{}
+			}(),
+			wantModel: &Model{
+				TableName: "scanner_model",
+			},
+			fields: []*Field{
+				{
+					GoName:  "NullString",
+					ColName: "null_string",
+					Typ:     reflect.TypeOf(sql.NullString{}),
+					Index:   []int{0},
 				},
 			},
 		},
+		{
+			name: "duplicate field",
+			input: func() any {
+				type DuplicateField struct {
+					BaseModel
+					Id int64
+				}
+				return &DuplicateField{}
+			}(),
+			wantErr: errs.NewErrDuplicateField("Id"),
+		},
+		{
+			name: "duplicate column",
+			input: func() any {
+				type DuplicateColumn struct {
+					BaseModel
+					UserId int64 `orm:"column=id"`
+				}
+				return &DuplicateColumn{}
+			}(),
+			wantErr: errs.NewErrDuplicateColumn("id"),
+		},
+		{
+			name: "unsupported map",
+			input: func() any {
+				type MapField struct {
+					Attrs map[string]string
+				}
+				return &MapField{}
+			}(),
+			wantErr: errs.NewErrUnsupportedFieldType("Attrs", reflect.TypeOf(map[string]string{})),
+		},
+		{
+			name: "unsupported struct",
+			input: func() any {
+				// 没有 embedded 标签的结构体不会被展开
+				type StructField struct {
+					Base *BaseModel
+				}
+				return &StructField{}
+			}(),
+			wantErr: errs.NewErrUnsupportedFieldType("Base", reflect.TypeOf(&BaseModel{})),
+		},
+		{
+			name: "json",
+			input: func() any {
+				type JSONModel struct {
+					Settings map[string]string `orm:"json"`
+					Base     *BaseModel        `orm:"json"`
+				}
+				return &JSONModel{}
+			}(),
+			wantModel: &Model{
+				TableName: "json_model",
+			},
+			fields: []*Field{
+				{
+					GoName:  "Settings",
+					ColName: "settings",
+					Typ:     reflect.TypeOf(map[string]string{}),
+					Index:   []int{0},
+					JSON:    true,
+				},
+				{
+					GoName:  "Base",
+					ColName: "base",
+					Typ:     reflect.TypeOf(&BaseModel{}),
+					Offset:  8,
+					Index:   []int{1},
+					JSON:    true,
+				},
+			},
+		},
+		{
+			name: "embedded json",
+			input: func() any {
+				type EmbeddedJSON struct {
+					Base BaseModel `orm:"embedded,json"`
+				}
+				return &EmbeddedJSON{}
+			}(),
+			wantErr: errs.NewErrInvalidTagContent("Base", "embedded,json"),
+		},
+		{
+			name: "embedded not struct",
+			input: func() any {
+				type EmbeddedNotStruct struct {
+					Name string `orm:"embedded"`
+				}
+				return &EmbeddedNotStruct{}
+			}(),
+			wantErr: errs.NewErrInvalidTagContent("Name", "embedded"),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			r := registry{}
+			r := NewRegistry()
 			m, err := r.Register(tt.input)
 			assert.Equal(t, tt.wantErr, err)
 			if err != nil {
 				return
 			}
+			fieldMap := make(map[string]*Field, len(tt.fields))
+			columnMap := make(map[string]*Field, len(tt.fields))
+			var pks []*Field
+			for _, fd := range tt.fields {
+				fieldMap[fd.GoName] = fd
+				columnMap[fd.ColName] = fd
+				if fd.PrimaryKey {
+					pks = append(pks, fd)
+				}
+			}
+			tt.wantModel.FieldMap = fieldMap
+			tt.wantModel.ColumnMap = columnMap
+			tt.wantModel.Fields = tt.fields
+			tt.wantModel.PrimaryKeys = pks
 			assert.Equal(t, tt.wantModel, m)
 		})
 	}
 }
 
+func TestRegistry_Register(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         any
+		opts          []ModelOpt
+		wantTableName string
+		wantSchema    string
+		// wantColumns 列名对应的字段名
+		wantColumns map[string]string
+		wantErr     error
+	}{
+		{
+			name:          "table name interface",
+			input:         &CustomTableName{},
+			wantTableName: "custom_table_name_t",
+			wantColumns:   map[string]string{"name": "Name"},
+		},
+		{
+			name:          "empty table name interface",
+			input:         &EmptyTableName{},
+			wantTableName: "empty_table_name",
+			wantColumns:   map[string]string{"name": "Name"},
+		},
+		{
+			name:          "with table name",
+			input:         &CustomTableName{},
+			opts:          []ModelOpt{ModelWithTableName("custom")},
+			wantTableName: "custom",
+			wantColumns:   map[string]string{"name": "Name"},
+		},
+		{
+			name:    "with empty table name",
+			input:   &CustomTableName{},
+			opts:    []ModelOpt{ModelWithTableName("")},
+			wantErr: errs.ErrEmptyTableName,
+		},
+		{
+			name:          "with column name",
+			input:         &TestModel{},
+			opts:          []ModelOpt{ModelWithColumnName("FirstName", "first_name_t")},
+			wantTableName: "test_model",
+			wantColumns: map[string]string{
+				"id":           "Id",
+				"first_name_t": "FirstName",
+				"age":          "Age",
+				"last_name":    "LastName",
+			},
+		},
+		{
+			name:    "with unknown column name",
+			input:   &TestModel{},
+			opts:    []ModelOpt{ModelWithColumnName("Gender", "gender")},
+			wantErr: errs.NewErrUnKnowField("Gender"),
+		},
+		{
+			name:    "with duplicate column name",
+			input:   &TestModel{},
+			opts:    []ModelOpt{ModelWithColumnName("FirstName", "age")},
+			wantErr: errs.NewErrDuplicateColumn("age"),
+		},
+		{
+			name:          "with schema",
+			input:         &CustomTableName{},
+			opts:          []ModelOpt{ModelWithSchema("analytics")},
+			wantTableName: "custom_table_name_t",
+			wantSchema:    "analytics",
+			wantColumns:   map[string]string{"name": "Name"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			m, err := r.Register(tt.input, tt.opts...)
+			assert.Equal(t, tt.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.wantTableName, m.TableName)
+			assert.Equal(t, tt.wantSchema, m.Schema)
+			cols := make(map[string]string, len(m.ColumnMap))
+			for col, fd := range m.ColumnMap {
+				assert.Equal(t, col, fd.ColName)
+				cols[col] = fd.GoName
+			}
+			assert.Equal(t, tt.wantColumns, cols)
+
+			// Get 返回注册的模型
+			got, err := r.Get(tt.input)
+			assert.NoError(t, err)
+			assert.Same(t, m, got)
+		})
+	}
+}
+
+func TestRegistry_Get(t *testing.T) {
+	r := NewRegistry()
+	m, err := r.Get(&TestModel{})
+	assert.NoError(t, err)
+	got, err := r.Get(&TestModel{})
+	assert.NoError(t, err)
+	assert.Same(t, m, got)
+
+	// 重新注册会覆盖之前的模型
+	registered, err := r.Register(&TestModel{}, ModelWithTableName("test_model_t"))
+	assert.NoError(t, err)
+	got, err = r.Get(&TestModel{})
+	assert.NoError(t, err)
+	assert.Same(t, registered, got)
+	assert.Equal(t, "test_model_t", got.TableName)
+}
+
+type CustomTableName struct {
+	Name string
+}
+
+func (c CustomTableName) TableName() string {
+	return "custom_table_name_t"
+}
+
+type EmptyTableName struct {
+	Name string
+}
+
+func (e *EmptyTableName) TableName() string {
+	return ""
+}
+
 type TestModel struct {
 	Id        int64
 	FirstName string
 	Age       int8
 	LastName  *sql.NullString
 }
+
+type BaseModel struct {
+	Id         int64 `orm:"primary_key"`
+	CreateTime int64
+}
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy 在没有指定表名和列名的时候，把结构体名和字段名转换为表名和列名
+type NamingStrategy interface {
+	TableName(name string) string
+	ColumnName(name string) string
+}
+
+type NamingOpt func(n *naming)
+
+// NamingWithTablePrefix 表名加上前缀，例如 t_user
+func NamingWithTablePrefix(prefix string) NamingOpt {
+	return func(n *naming) {
+		n.tablePrefix = prefix
+	}
+}
+
+// NamingWithPluralTable 表名使用复数，例如 User 对应 users
+func NamingWithPluralTable() NamingOpt {
+	return func(n *naming) {
+		n.pluralTable = true
+	}
+}
+
+// SnakeCase 默认的命名策略，UserID 转换为 user_id
+func SnakeCase(opts ...NamingOpt) NamingStrategy {
+	return newNaming(snakeCase, opts...)
+}
+
+// CamelCase UserID 转换为 userID
+func CamelCase(opts ...NamingOpt) NamingStrategy {
+	return newNaming(camelCase, opts...)
+}
+
+// Verbatim 直接使用结构体名和字段名
+func Verbatim(opts ...NamingOpt) NamingStrategy {
+	return newNaming(func(name string) string { return name }, opts...)
+}
+
+type naming struct {
+	convert     func(name string) string
+	tablePrefix string
+	pluralTable bool
+}
+
+func newNaming(convert func(name string) string, opts ...NamingOpt) NamingStrategy {
+	res := &naming{
+		convert: convert,
+	}
+	for _, opt := range opts {
+		opt(res)
+	}
+	return res
+}
+
+func (n *naming) TableName(name string) string {
+	name = n.convert(name)
+	if n.pluralTable {
+		name = plural(name)
+	}
+	return n.tablePrefix + name
+}
+
+func (n *naming) ColumnName(name string) string {
+	return n.convert(name)
+}
+
+// snakeCase 连续的大写字母被当作一个单词，例如 HTTPServer 转换为 http_server
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (isWordEnd(runes[i-1]) || (unicode.IsUpper(runes[i-1]) && isWordStart(runes, i))) {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// camelCase 把开头的单词转换为小写，例如 UserID 转换为 userID，HTTPServer 转换为 httpServer
+func camelCase(name string) string {
+	runes := []rune(name)
+	for i := 0; i < len(runes) && unicode.IsUpper(runes[i]); i++ {
+		// 后面是小写字母，说明这是下一个单词的开头
+		if i > 0 && isWordStart(runes, i) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// isWordStart 连续的大写字母中，后面是小写字母的 runes[i] 是下一个单词的开头，例如 HTTPServer 中的 S
+// 但是复数的 s 属于前面的缩写，例如 UserIDs 和 URLsCount 中的 s
+func isWordStart(runes []rune, i int) bool {
+	if i+1 >= len(runes) || !unicode.IsLower(runes[i+1]) {
+		return false
+	}
+	return runes[i+1] != 's' || !isPluralS(runes, i+1)
+}
+
+// isPluralS 缩写后面的 s 在结尾，或者后面是新单词的开头的时候是复数
+// Is 是常见的单词，例如 UserIDIsValid，所以 I 后面跟着 s 和新单词的时候不当作复数
+func isPluralS(runes []rune, i int) bool {
+	if i+1 == len(runes) {
+		return true
+	}
+	return unicode.IsUpper(runes[i+1]) && runes[i-1] != 'I'
+}
+
+// isWordEnd 小写字母、数字和没有大小写的字符（例如中文）后面的大写字母是新单词的开头
+func isWordEnd(r rune) bool {
+	return r != '_' && !unicode.IsUpper(r)
+}
+
+// plural 简单的英文复数规则
+func plural(name string) string {
+	switch {
+	case name == "":
+		return name
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "z"),
+		strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiou", rune(name[len(name)-2])):
+		return name[:len(name)-1] + "ies"
+	default:
+		return name + "s"
+	}
+}
@@ -0,0 +1,203 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNamingStrategy(t *testing.T) {
+	tests := []struct {
+		name      string
+		naming    NamingStrategy
+		input     string
+		wantTable string
+		wantCol   string
+	}{
+		{
+			name:      "snake case",
+			naming:    SnakeCase(),
+			input:     "UserName",
+			wantTable: "user_name",
+			wantCol:   "user_name",
+		},
+		{
+			name:      "snake case acronym",
+			naming:    SnakeCase(),
+			input:     "UserID",
+			wantTable: "user_id",
+			wantCol:   "user_id",
+		},
+		{
+			name:      "snake case leading acronym",
+			naming:    SnakeCase(),
+			input:     "HTTPServer",
+			wantTable: "http_server",
+			wantCol:   "http_server",
+		},
+		{
+			name:      "snake case plural acronym",
+			naming:    SnakeCase(),
+			input:     "UserIDs",
+			wantTable: "user_ids",
+			wantCol:   "user_ids",
+		},
+		{
+			name:      "snake case leading plural acronym",
+			naming:    SnakeCase(),
+			input:     "APIs",
+			wantTable: "apis",
+			wantCol:   "apis",
+		},
+		{
+			name:      "snake case plural acronym in middle",
+			naming:    SnakeCase(),
+			input:     "URLsCount",
+			wantTable: "urls_count",
+			wantCol:   "urls_count",
+		},
+		{
+			name:      "snake case acronym before Is",
+			naming:    SnakeCase(),
+			input:     "UserIDIsValid",
+			wantTable: "user_id_is_valid",
+			wantCol:   "user_id_is_valid",
+		},
+		{
+			name:      "snake case leading acronym before Is",
+			naming:    SnakeCase(),
+			input:     "APIIsUp",
+			wantTable: "api_is_up",
+			wantCol:   "api_is_up",
+		},
+		{
+			name:      "snake case acronym before s word",
+			naming:    SnakeCase(),
+			input:     "IDSet",
+			wantTable: "id_set",
+			wantCol:   "id_set",
+		},
+		{
+			name:      "snake case digit",
+			naming:    SnakeCase(),
+			input:     "Md5Hash",
+			wantTable: "md5_hash",
+			wantCol:   "md5_hash",
+		},
+		{
+			name:      "snake case unicode",
+			naming:    SnakeCase(),
+			input:     "ÜberName",
+			wantTable: "über_name",
+			wantCol:   "über_name",
+		},
+		{
+			name:      "snake case chinese",
+			naming:    SnakeCase(),
+			input:     "用户Name",
+			wantTable: "用户_name",
+			wantCol:   "用户_name",
+		},
+		{
+			name:      "camel case",
+			naming:    CamelCase(),
+			input:     "UserID",
+			wantTable: "userID",
+			wantCol:   "userID",
+		},
+		{
+			name:      "camel case leading acronym",
+			naming:    CamelCase(),
+			input:     "HTTPServer",
+			wantTable: "httpServer",
+			wantCol:   "httpServer",
+		},
+		{
+			name:      "camel case leading plural acronym",
+			naming:    CamelCase(),
+			input:     "APIs",
+			wantTable: "apis",
+			wantCol:   "apis",
+		},
+		{
+			name:      "camel case leading acronym before Is",
+			naming:    CamelCase(),
+			input:     "APIIsUp",
+			wantTable: "apiIsUp",
+			wantCol:   "apiIsUp",
+		},
+		{
+			name:      "camel case all upper",
+			naming:    CamelCase(),
+			input:     "ID",
+			wantTable: "id",
+			wantCol:   "id",
+		},
+		{
+			name:      "verbatim",
+			naming:    Verbatim(),
+			input:     "UserID",
+			wantTable: "UserID",
+			wantCol:   "UserID",
+		},
+		{
+			name:      "table prefix",
+			naming:    SnakeCase(NamingWithTablePrefix("t_")),
+			input:     "UserName",
+			wantTable: "t_user_name",
+			wantCol:   "user_name",
+		},
+		{
+			name:      "plural table",
+			naming:    SnakeCase(NamingWithPluralTable()),
+			input:     "User",
+			wantTable: "users",
+			wantCol:   "user",
+		},
+		{
+			name:      "plural table ies",
+			naming:    SnakeCase(NamingWithPluralTable()),
+			input:     "Category",
+			wantTable: "categories",
+			wantCol:   "category",
+		},
+		{
+			name:      "plural table es",
+			naming:    SnakeCase(NamingWithPluralTable(), NamingWithTablePrefix("t_")),
+			input:     "Address",
+			wantTable: "t_addresses",
+			wantCol:   "address",
+		},
+		{
+			name:      "plural table vowel y",
+			naming:    Verbatim(NamingWithPluralTable()),
+			input:     "Day",
+			wantTable: "Days",
+			wantCol:   "Day",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantTable, tt.naming.TableName(tt.input))
+			assert.Equal(t, tt.wantCol, tt.naming.ColumnName(tt.input))
+		})
+	}
+}
+
+func TestRegistry_WithNaming(t *testing.T) {
+	type UserInfo struct {
+		UserID   int64
+		NickName string `orm:"column=nick"`
+	}
+	r := NewRegistry(WithNaming(CamelCase(NamingWithPluralTable(), NamingWithTablePrefix("t_"))))
+	m, err := r.Get(&UserInfo{})
+	assert.NoError(t, err)
+	assert.Equal(t, "t_userInfos", m.TableName)
+	assert.Equal(t, "userID", m.FieldMap["UserID"].ColName)
+	// 标签指定的列名不受命名策略影响
+	assert.Equal(t, "nick", m.FieldMap["NickName"].ColName)
+
+	// TableName 接口指定的表名不受命名策略影响
+	m, err = r.Get(&CustomTableName{})
+	assert.NoError(t, err)
+	assert.Equal(t, "custom_table_name_t", m.TableName)
+}
@@ -1,11 +1,13 @@
 package model
 
 import (
+	"database/sql"
 	"github.com/soluble1/morm/internal/errs"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
-	"unicode"
+	"time"
 )
 
 type Registry interface {
@@ -16,27 +18,55 @@ type Registry interface {
 
 type registry struct {
 	models sync.Map
+	naming NamingStrategy
 }
 
-func NewRegistry() Registry {
-	return &registry{}
+type RegistryOpt func(r *registry)
+
+// WithNaming 设置表名和列名的命名策略，默认是 SnakeCase
+func WithNaming(naming NamingStrategy) RegistryOpt {
+	return func(r *registry) {
+		r.naming = naming
+	}
 }
 
+func NewRegistry(opts ...RegistryOpt) Registry {
+	res := &registry{
+		naming: SnakeCase(),
+	}
+	for _, opt := range opts {
+		opt(res)
+	}
+	return res
+}
+
+// Get 优先返回通过 Register 注册的模型，没有注册过的使用默认规则解析
 func (r *registry) Get(val any) (*Model, error) {
 	typ := reflect.TypeOf(val)
 	m, ok := r.models.Load(typ)
 	if ok {
 		return m.(*Model), nil
 	}
-	m, err := r.Register(val)
+	res, err := r.parseModel(val)
 	if err != nil {
 		return nil, err
 	}
-	r.models.Store(typ, m)
+	// 并发解析同一个类型的时候，以先存进去的为准
+	m, _ = r.models.LoadOrStore(typ, res)
 	return m.(*Model), nil
 }
 
+// Register 解析模型并应用 opts，结果会覆盖之前注册的模型
 func (r *registry) Register(val any, opts ...ModelOpt) (*Model, error) {
+	res, err := r.parseModel(val, opts...)
+	if err != nil {
+		return nil, err
+	}
+	r.models.Store(reflect.TypeOf(val), res)
+	return res, nil
+}
+
+func (r *registry) parseModel(val any, opts ...ModelOpt) (*Model, error) {
 	if val == nil {
 		return nil, errs.ErrInputNil
 	}
@@ -45,29 +75,14 @@ func (r *registry) Register(val any, opts ...ModelOpt) (*Model, error) {
 		return nil, errs.ErrPointerOnly
 	}
 	typ = typ.Elem()
-	numField := typ.NumField()
 
-	fieldMap := make(map[string]*Field, numField)
-	colMap := make(map[string]*Field, numField)
-	columns := make([]*Field, numField)
-	for i := 0; i < numField; i++ {
-		fd := typ.Field(i)
-		ormTagStrs := r.parseTag(fd.Tag)
-		var colName string
-		colName, ok := ormTagStrs["column"]
-		if !ok || colName == "" {
-			colName = underscoreName(fd.Name)
-		}
-		fdData := &Field{
-			ColName: colName,
-			Typ:     fd.Type,
-			GoName:  fd.Name,
-			Offset:  fd.Offset,
-			Index:   fd.Index,
-		}
-		fieldMap[fd.Name] = fdData
-		colMap[colName] = fdData
-		columns[i] = fdData
+	res := &Model{
+		FieldMap:  make(map[string]*Field, typ.NumField()),
+		ColumnMap: make(map[string]*Field, typ.NumField()),
+		Fields:    make([]*Field, 0, typ.NumField()),
+	}
+	if err := r.parseFields(res, typ, nil, 0, nil, "", ""); err != nil {
+		return nil, err
 	}
 
 	var tableName string
@@ -75,15 +90,9 @@ func (r *registry) Register(val any, opts ...ModelOpt) (*Model, error) {
 		tableName = tn.TableName()
 	}
 	if tableName == "" {
-		tableName = underscoreName(typ.Name())
-	}
-
-	res := &Model{
-		TableName: underscoreName(typ.Name()),
-		FieldMap:  fieldMap,
-		ColumnMap: colMap,
-		Fields:    columns,
+		tableName = r.naming.TableName(typ.Name())
 	}
+	res.TableName = tableName
 
 	for _, opt := range opts {
 		if err := opt(res); err != nil {
@@ -93,33 +102,206 @@ func (r *registry) Register(val any, opts ...ModelOpt) (*Model, error) {
 	return res, nil
 }
 
-func (r *registry) parseTag(tag reflect.StructTag) map[string]string {
-	ormTag := tag.Get("orm")
+// parseFields 解析结构体的字段，嵌入的结构体会被展开
+// index 和 offset 是 typ 在最外层结构体中的位置，parent 是 typ 所在的嵌入指针，prefix 是列名前缀
+// namePrefix 是字段名前缀，具名的嵌入结构体中的字段使用 Home.City 这样的字段名，同一个结构体可以嵌入多次
+func (r *registry) parseFields(m *Model, typ reflect.Type,
+	index []int, offset uintptr, parent *Field, prefix string, namePrefix string) error {
+	for i := 0; i < typ.NumField(); i++ {
+		fd := typ.Field(i)
+		ormTagStrs, err := r.parseTag(fd)
+		if err != nil {
+			return err
+		}
+		// orm:"-" 表示忽略这个字段
+		if _, ok := ormTagStrs[tagIgnore]; ok {
+			continue
+		}
+		fdIndex := make([]int, 0, len(index)+1)
+		fdIndex = append(append(fdIndex, index...), i)
+
+		embedded, err := r.isEmbedded(fd, ormTagStrs)
+		if err != nil {
+			return err
+		}
+		if embedded {
+			subTyp, subOffset, subParent := fd.Type, offset+fd.Offset, parent
+			// 嵌入的是指针，里面字段的偏移量相对于指针指向的结构体
+			if subTyp.Kind() == reflect.Ptr {
+				subParent = &Field{
+					GoName: namePrefix + fd.Name,
+					Typ:    subTyp,
+					Offset: offset + fd.Offset,
+					Index:  fdIndex,
+					Parent: parent,
+				}
+				subTyp, subOffset = subTyp.Elem(), 0
+			}
+			subNamePrefix := namePrefix
+			if !fd.Anonymous {
+				subNamePrefix = namePrefix + fd.Name + "."
+			}
+			err = r.parseFields(m, subTyp, fdIndex, subOffset, subParent,
+				prefix+ormTagStrs[tagPrefix], subNamePrefix)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		fdData, err := r.newField(fd, ormTagStrs)
+		if err != nil {
+			return err
+		}
+		fdData.GoName = namePrefix + fdData.GoName
+		fdData.ColName = prefix + fdData.ColName
+		fdData.Offset = offset + fd.Offset
+		fdData.Index = fdIndex
+		fdData.Parent = parent
+		if _, ok := m.FieldMap[fdData.GoName]; ok {
+			return errs.NewErrDuplicateField(fdData.GoName)
+		}
+		if _, ok := m.ColumnMap[fdData.ColName]; ok {
+			return errs.NewErrDuplicateColumn(fdData.ColName)
+		}
+		m.FieldMap[fdData.GoName] = fdData
+		m.ColumnMap[fdData.ColName] = fdData
+		m.Fields = append(m.Fields, fdData)
+		if fdData.PrimaryKey {
+			m.PrimaryKeys = append(m.PrimaryKeys, fdData)
+		}
+	}
+	return nil
+}
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// scannable 字段能不能作为 sql.Rows.Scan 的参数
+// 支持基本类型、[]byte、time.Time、实现了 sql.Scanner 的类型以及它们的指针
+func scannable(typ reflect.Type) bool {
+	if reflect.PointerTo(typ).Implements(scannerType) {
+		return true
+	}
+	switch typ.Kind() {
+	case reflect.Bool, reflect.String, reflect.Interface,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return typ.Elem().Kind() == reflect.Uint8
+	case reflect.Ptr:
+		return scannable(typ.Elem())
+	case reflect.Struct:
+		return typ == timeType
+	default:
+		return false
+	}
+}
+
+// isEmbedded 匿名的结构体或者结构体指针会被展开，除非它实现了 sql.Scanner，例如 sql.NullString
+// 具名的结构体字段需要使用 orm:"embedded" 标签，使用 orm:"json" 标签的字段不会被展开
+func (r *registry) isEmbedded(fd reflect.StructField, tags map[string]string) (bool, error) {
+	typ := fd.Type
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	_, embedded := tags[tagEmbedded]
+	_, isJSON := tags[tagJSON]
+	if embedded {
+		if typ.Kind() != reflect.Struct || isJSON {
+			return false, errs.NewErrInvalidTagContent(fd.Name, fd.Tag.Get("orm"))
+		}
+		return true, nil
+	}
+	if isJSON || !fd.Anonymous || typ.Kind() != reflect.Struct {
+		return false, nil
+	}
+	return !reflect.PointerTo(typ).Implements(scannerType), nil
+}
+
+// orm 标签中支持的 key
+const (
+	tagColumn        = "column"
+	tagPrimaryKey    = "primary_key"
+	tagAutoIncrement = "auto_increment"
+	tagIgnore        = "-"
+	tagDefault       = "default"
+	tagNullable      = "nullable"
+	tagSize          = "size"
+	tagType          = "type"
+	tagReadOnly      = "readonly"
+	tagUnique        = "unique"
+	tagEmbedded      = "embedded"
+	tagPrefix        = "prefix"
+	tagJSON          = "json"
+)
+
+func (r *registry) newField(fd reflect.StructField, tags map[string]string) (*Field, error) {
+	_, isJSON := tags[tagJSON]
+	// JSON 字段会先扫描到 []byte 里面再解码
+	if !isJSON && !scannable(fd.Type) {
+		return nil, errs.NewErrUnsupportedFieldType(fd.Name, fd.Type)
+	}
+	colName, ok := tags[tagColumn]
+	if !ok || colName == "" {
+		colName = r.naming.ColumnName(fd.Name)
+	}
+	res := &Field{
+		ColName: colName,
+		Typ:     fd.Type,
+		GoName:  fd.Name,
+		Offset:  fd.Offset,
+		Index:   fd.Index,
+
+		Default: tags[tagDefault],
+		SQLType: tags[tagType],
+	}
+	_, res.PrimaryKey = tags[tagPrimaryKey]
+	_, res.AutoIncrement = tags[tagAutoIncrement]
+	_, res.Nullable = tags[tagNullable]
+	_, res.ReadOnly = tags[tagReadOnly]
+	_, res.Unique = tags[tagUnique]
+	res.JSON = isJSON
+	if size, ok := tags[tagSize]; ok {
+		val, err := strconv.Atoi(size)
+		if err != nil || val < 0 {
+			return nil, errs.NewErrInvalidTagContent(fd.Name, fd.Tag.Get("orm"))
+		}
+		res.Size = val
+	}
+	return res, nil
+}
+
+// parseTag 标签之间用逗号分隔，值用等号分隔，例如 `orm:"column=id,primary_key"`
+// 值里面不能包含逗号
+func (r *registry) parseTag(fd reflect.StructField) (map[string]string, error) {
+	ormTag, ok := fd.Tag.Lookup("orm")
+	if !ok || ormTag == "" {
+		return map[string]string{}, nil
+	}
 	strs := strings.Split(ormTag, ",")
 	res := make(map[string]string, len(strs))
 	for _, str := range strs {
-		segs := strings.Split(str, "=")
-		key := segs[0]
+		segs := strings.SplitN(str, "=", 2)
+		key := strings.TrimSpace(segs[0])
 		var val = ""
 		if len(segs) > 1 {
-			val = segs[1]
+			val = strings.TrimSpace(segs[1])
 		}
-		res[key] = val
-	}
-	return res
-}
-
-func underscoreName(name string) string {
-	var buf []byte
-	for i, v := range name {
-		if unicode.IsUpper(v) {
-			if i != 0 {
-				buf = append(buf, '_')
+		switch key {
+		case tagColumn, tagDefault, tagSize, tagType, tagPrefix:
+		case tagPrimaryKey, tagAutoIncrement, tagIgnore, tagNullable, tagReadOnly, tagUnique, tagEmbedded, tagJSON:
+			if len(segs) > 1 {
+				return nil, errs.NewErrInvalidTagContent(fd.Name, ormTag)
 			}
-			buf = append(buf, byte(unicode.ToLower(v)))
-		} else {
-			buf = append(buf, byte(v))
+		default:
+			return nil, errs.NewErrInvalidTagContent(fd.Name, ormTag)
 		}
+		res[key] = val
 	}
-	return string(buf)
+	return res, nil
 }
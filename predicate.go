@@ -1,11 +1,32 @@
 package morm
 
+import "reflect"
+
 type op string
 
 const (
-	opEQ = "="
-	opLT = "<"
-	opGT = ">"
+	opEQ      = "="
+	opNEQ     = "!="
+	opLT      = "<"
+	opLTEQ    = "<="
+	opGT      = ">"
+	opGTEQ    = ">="
+	opIN      = "IN"
+	opNOTIN   = "NOT IN"
+	opBETWEEN = "BETWEEN"
+	opLIKE    = "LIKE"
+	opNOTLIKE = "NOT LIKE"
+
+	opISNULL    = "IS NULL"
+	opISNOTNULL = "IS NOT NULL"
+
+	opADD   = "+"
+	opSUB   = "-"
+	opMULTI = "*"
+	opDIV   = "/"
+
+	opEXISTS    = "EXISTS"
+	opNOTEXISTS = "NOT EXISTS"
 
 	opNOT = "NOT"
 	opAND = "AND"
@@ -41,7 +62,10 @@ func (p1 Predicate) Or(p2 Predicate) Predicate {
 }
 
 type Column struct {
-	name string
+	// table 为 nil 表示使用 Selector 本身的模型
+	table TableReference
+	name  string
+	alias string
 }
 
 func (c Column) selectable() {}
@@ -54,6 +78,15 @@ func C(name string) Column {
 	return Column{name: name}
 }
 
+// As 别名，只在 SELECT 部分生效
+func (c Column) As(alias string) Column {
+	return Column{
+		table: c.table,
+		name:  c.name,
+		alias: alias,
+	}
+}
+
 func (c Column) Eq(args any) Predicate {
 	return Predicate{
 		left:  c,
@@ -62,6 +95,14 @@ func (c Column) Eq(args any) Predicate {
 	}
 }
 
+func (c Column) NotEq(args any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opNEQ,
+		right: valueOf(args),
+	}
+}
+
 func (c Column) Lt(args any) Predicate {
 	return Predicate{
 		left:  c,
@@ -70,6 +111,14 @@ func (c Column) Lt(args any) Predicate {
 	}
 }
 
+func (c Column) LtEq(args any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opLTEQ,
+		right: valueOf(args),
+	}
+}
+
 func (c Column) Gt(args any) Predicate {
 	return Predicate{
 		left:  c,
@@ -78,6 +127,139 @@ func (c Column) Gt(args any) Predicate {
 	}
 }
 
+func (c Column) GtEq(args any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opGTEQ,
+		right: valueOf(args),
+	}
+}
+
+func (c Column) Add(val any) MathExpr {
+	return MathExpr{
+		left:  c,
+		op:    opADD,
+		right: valueOf(val),
+	}
+}
+
+func (c Column) Sub(val any) MathExpr {
+	return MathExpr{
+		left:  c,
+		op:    opSUB,
+		right: valueOf(val),
+	}
+}
+
+func (c Column) Multi(val any) MathExpr {
+	return MathExpr{
+		left:  c,
+		op:    opMULTI,
+		right: valueOf(val),
+	}
+}
+
+func (c Column) Div(val any) MathExpr {
+	return MathExpr{
+		left:  c,
+		op:    opDIV,
+		right: valueOf(val),
+	}
+}
+
+// In 可以传入多个值，也可以只传入一个切片，例如 In(1, 2, 3) 或者 In([]int{1, 2, 3})
+// 值也可以是表达式，例如 In(C("Age"), C("Id").Add(1))
+// 没有值的时候返回恒为假的条件
+// 只传入一个子查询的时候和 InQuery 一样，例如 In(sub)
+func (c Column) In(vals ...any) Predicate {
+	if sub, ok := singleSubquery(vals); ok {
+		return c.InQuery(sub)
+	}
+	vals = expandValues(vals)
+	if len(vals) == 0 {
+		return Raw("1 = 0").AsPredicate()
+	}
+	return Predicate{
+		left:  c,
+		op:    opIN,
+		right: valueList{vals: vals},
+	}
+}
+
+// NotIn 没有值的时候返回恒为真的条件，只传入一个子查询的时候和 NotInQuery 一样
+func (c Column) NotIn(vals ...any) Predicate {
+	if sub, ok := singleSubquery(vals); ok {
+		return c.NotInQuery(sub)
+	}
+	vals = expandValues(vals)
+	if len(vals) == 0 {
+		return Raw("1 = 1").AsPredicate()
+	}
+	return Predicate{
+		left:  c,
+		op:    opNOTIN,
+		right: valueList{vals: vals},
+	}
+}
+
+func (c Column) Between(start any, end any) Predicate {
+	return Predicate{
+		left: c,
+		op:   opBETWEEN,
+		right: valueRange{
+			start: valueOf(start),
+			end:   valueOf(end),
+		},
+	}
+}
+
+func (c Column) Like(pattern string) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opLIKE,
+		right: valueOf(pattern),
+	}
+}
+
+func (c Column) NotLike(pattern string) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opNOTLIKE,
+		right: valueOf(pattern),
+	}
+}
+
+func (c Column) IsNull() Predicate {
+	return Predicate{
+		left: c,
+		op:   opISNULL,
+	}
+}
+
+func (c Column) IsNotNull() Predicate {
+	return Predicate{
+		left: c,
+		op:   opISNOTNULL,
+	}
+}
+
+// InQuery 例如 C("Id").InQuery(sub)
+func (c Column) InQuery(sub Subquery) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opIN,
+		right: sub,
+	}
+}
+
+func (c Column) NotInQuery(sub Subquery) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opNOTIN,
+		right: sub,
+	}
+}
+
 func Not(p Predicate) Predicate {
 	return Predicate{
 		left:  nil,
@@ -92,8 +274,57 @@ type Value struct {
 
 func (Value) expr() {}
 
+// valueOf 如果 val 本身就是表达式，例如 Column，那么直接使用
+// singleSubquery In(sub) 应该是 IN (SELECT ...)，而不是只有一个标量子查询的 IN ((SELECT ...))
+func singleSubquery(vals []any) (Subquery, bool) {
+	if len(vals) != 1 {
+		return Subquery{}, false
+	}
+	sub, ok := vals[0].(Subquery)
+	return sub, ok
+}
+
 func valueOf(val any) Expression {
-	return Value{
-		val: val,
+	switch v := val.(type) {
+	case Expression:
+		return v
+	default:
+		return Value{
+			val: val,
+		}
+	}
+}
+
+// valueList IN 右边的值列表，构造成 (?,?,?)
+type valueList struct {
+	vals []any
+}
+
+func (valueList) expr() {}
+
+// valueRange BETWEEN 右边的范围，构造成 ? AND ?
+type valueRange struct {
+	start Expression
+	end   Expression
+}
+
+func (valueRange) expr() {}
+
+// expandValues 只传入一个切片的时候展开这个切片，[]byte 是单个值不展开
+func expandValues(vals []any) []any {
+	if len(vals) != 1 {
+		return vals
+	}
+	if _, ok := vals[0].([]byte); ok {
+		return vals
+	}
+	val := reflect.ValueOf(vals[0])
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return vals
+	}
+	res := make([]any, 0, val.Len())
+	for i := 0; i < val.Len(); i++ {
+		res = append(res, val.Index(i).Interface())
 	}
+	return res
 }
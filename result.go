@@ -1,24 +1,43 @@
 package morm
 
-import "database/sql"
+import (
+	"database/sql"
+	"github.com/soluble1/morm/internal/errs"
+)
 
+// Result 零值没有 sql.Result，LastInsertId 和 RowsAffected 返回 ErrNoResult
 type Result struct {
 	res sql.Result
 	err error
 }
 
-// LastInsertId 最后插入的行的 id
+// LastInsertId 最后插入的行的 id，MySQL 批量插入的时候是第一行的 id
 func (r Result) LastInsertId() (int64, error) {
+	if r.res == nil {
+		return 0, r.noResultErr()
+	}
+	id, err := r.res.LastInsertId()
 	if r.err != nil {
-		return 0, r.err
+		return id, r.err
 	}
-	return r.res.LastInsertId()
+	return id, err
 }
 
-// RowsAffected 受影响的行数
+// RowsAffected 受影响的行数，分批插入失败的时候同时返回已经执行的批次的行数和错误
 func (r Result) RowsAffected() (int64, error) {
+	if r.res == nil {
+		return 0, r.noResultErr()
+	}
+	n, err := r.res.RowsAffected()
+	if r.err != nil {
+		return n, r.err
+	}
+	return n, err
+}
+
+func (r Result) noResultErr() error {
 	if r.err != nil {
-		return 0, r.err
+		return r.err
 	}
-	return r.res.RowsAffected()
+	return errs.ErrNoResult
 }
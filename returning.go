@@ -0,0 +1,81 @@
+package morm
+
+import (
+	"context"
+	"database/sql/driver"
+	"github.com/soluble1/morm/internal/errs"
+)
+
+// returning INSERT、UPDATE 和 DELETE 的 RETURNING 子句
+type returning struct {
+	// set 调用过 Returning，没有指定列的时候返回所有的列
+	set  bool
+	cols []string
+}
+
+// buildReturning 不支持 RETURNING 的方言返回错误，例如 MySQL
+func (b *builder) buildReturning(r returning) error {
+	if !r.set {
+		return nil
+	}
+	if !b.dialect.SupportsReturning() {
+		return errs.ErrUnsupportedReturning
+	}
+	b.sb.WriteString(" RETURNING ")
+	if len(r.cols) == 0 {
+		b.sb.WriteByte('*')
+		return nil
+	}
+	for i, col := range r.cols {
+		if i > 0 {
+			b.sb.WriteByte(',')
+		}
+		fd, ok := b.model.FieldMap[col]
+		if !ok {
+			return errs.NewErrUnKnowField(col)
+		}
+		b.quote(fd.ColName)
+	}
+	return nil
+}
+
+// getMulti 执行查询并把每一行转换为一个 T
+func getMulti[T any](ctx context.Context, sess Session, qc *QueryContext) *QueryResult {
+	q, err := qc.Query()
+	if err != nil {
+		return &QueryResult{Err: err}
+	}
+
+	rows, err := sess.queryContext(ctx, q.SQL, q.Args...)
+	if err != nil {
+		return &QueryResult{Err: err}
+	}
+	defer func() { _ = rows.Close() }()
+
+	res := make([]*T, 0)
+	val := sess.getCore().valCreator(&res, qc.Model)
+	if err = val.GetStructs(rows); err != nil {
+		return &QueryResult{Err: err}
+	}
+	return &QueryResult{Result: res}
+}
+
+// execReturning 使用 RETURNING 的 Exec，RowsAffected 是返回的行数，不支持 LastInsertId
+func execReturning[T any](ctx context.Context, sess Session, qc *QueryContext) Result {
+	qr := sess.getCore().handle(ctx, qc, func(ctx context.Context, qc *QueryContext) *QueryResult {
+		res := getMulti[T](ctx, sess, qc)
+		if res.Err != nil {
+			return res
+		}
+		return &QueryResult{Result: driver.RowsAffected(len(res.Result.([]*T)))}
+	})
+	return newResult(qr)
+}
+
+// returningMulti Updater 和 Deleter 以 []*T 的形式返回 RETURNING 的结果
+func returningMulti[T any](ctx context.Context, sess Session, qc *QueryContext) ([]*T, error) {
+	qr := sess.getCore().handle(ctx, qc, func(ctx context.Context, qc *QueryContext) *QueryResult {
+		return getMulti[T](ctx, sess, qc)
+	})
+	return resultOf[[]*T](qr)
+}
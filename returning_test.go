@@ -0,0 +1,118 @@
+package morm
+
+import (
+	"context"
+	"github.com/soluble1/morm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestReturning_Build(t *testing.T) {
+	mysqlDB := memoryDB(t)
+	pgDB, err := OpenDB(mysqlDB.db, DBWithDialect(DialectPostgreSQL))
+	require.NoError(t, err)
+	sqliteDB, err := OpenDB(mysqlDB.db, DBWithDialect(DialectSQLite))
+	require.NoError(t, err)
+	tests := []struct {
+		name      string
+		b         QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "insert",
+			b: NewInserter[TagModel](pgDB).Values(&TagModel{Name: "xiao"}, &TagModel{Name: "ma"}).
+				Returning("Id", "Version"),
+			wantQuery: &Query{
+				SQL:  `INSERT INTO "tag_model"("name") VALUES($1),($2) RETURNING "id","version";`,
+				Args: []any{"xiao", "ma"},
+			},
+		},
+		{
+			name: "upsert",
+			b: NewInserter[TagModel](pgDB).Values(&TagModel{Name: "xiao"}).Columns("Id", "Name").
+				Upsert().ConflictColumns("Id").Update(C("Name")).Returning("Version"),
+			wantQuery: &Query{
+				SQL: `INSERT INTO "tag_model"("id","name") VALUES($1,$2) ` +
+					`ON CONFLICT ("id") DO UPDATE SET "name"=EXCLUDED."name" RETURNING "version";`,
+				Args: []any{int64(0), "xiao"},
+			},
+		},
+		{
+			name: "update all columns",
+			b:    NewUpdater[TagModel](sqliteDB).Set(C("Name").Eq("xiao")).Where(C("Id").Eq(1)).Returning(),
+			wantQuery: &Query{
+				SQL:  "UPDATE `tag_model` SET `name` = ? WHERE `id` = ? RETURNING *;",
+				Args: []any{"xiao", 1},
+			},
+		},
+		{
+			name: "delete",
+			b:    NewDeleter[TagModel](pgDB).Where(C("Id").Eq(1)).Returning("Id", "Name"),
+			wantQuery: &Query{
+				SQL:  `DELETE FROM "tag_model" WHERE "id" = $1 RETURNING "id","name";`,
+				Args: []any{1},
+			},
+		},
+		{
+			name:    "unknown column",
+			b:       NewDeleter[TagModel](pgDB).Where(C("Id").Eq(1)).Returning("Memo"),
+			wantErr: errs.NewErrUnKnowField("Memo"),
+		},
+		{
+			name:    "mysql",
+			b:       NewInserter[TagModel](mysqlDB).Values(&TagModel{Name: "xiao"}).Returning("Id"),
+			wantErr: ErrUnsupportedReturning,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := tt.b.Build()
+			assert.Equal(t, tt.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.wantQuery, q)
+		})
+	}
+}
+
+func TestReturning_Exec(t *testing.T) {
+	for _, opt := range []DBOption{DBUseReflectValuer(), func(db *DB) {}} {
+		db, err := Open("sqlite3", "file:returning.db?cache=shared&mode=memory", DBWithDialect(DialectSQLite), opt)
+		require.NoError(t, err)
+		ctx := context.Background()
+		_, err = db.db.ExecContext(ctx, "DROP TABLE IF EXISTS `tag_model`")
+		require.NoError(t, err)
+		_, err = db.db.ExecContext(ctx, "CREATE TABLE `tag_model`("+
+			"`id` INTEGER PRIMARY KEY AUTOINCREMENT, `name` TEXT, `version` INTEGER DEFAULT 1)")
+		require.NoError(t, err)
+
+		// 数据库生成的值写回到传入的结构体
+		vals := []*TagModel{{Name: "xiao"}, {Name: "ma"}, {Name: "lao"}}
+		affected, err := NewInserter[TagModel](db).Values(vals...).Returning("Id", "Version").
+			Exec(ctx).RowsAffected()
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), affected)
+		assert.Equal(t, []*TagModel{
+			{Id: 1, Name: "xiao", Version: 1},
+			{Id: 2, Name: "ma", Version: 1},
+			{Id: 3, Name: "lao", Version: 1},
+		}, vals)
+
+		updated, err := NewUpdater[TagModel](db).Set(C("Version").Eq(C("Version").Add(1))).
+			Where(C("Id").Gt(1)).Returning("Id", "Version").GetMulti(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []*TagModel{{Id: 2, Version: 2}, {Id: 3, Version: 2}}, updated)
+
+		deleted, err := NewDeleter[TagModel](db).Where(C("Id").Eq(3)).GetMulti(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []*TagModel{{Id: 3, Name: "lao", Version: 2}}, deleted)
+
+		affected, err = NewDeleter[TagModel](db).Where(C("Id").Lt(3)).Returning("Id").Exec(ctx).RowsAffected()
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), affected)
+		_ = db.db.Close()
+	}
+}
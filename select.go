@@ -2,20 +2,24 @@ package morm
 
 import (
 	"context"
-	"errors"
 	"github.com/soluble1/morm/internal/errs"
-	"reflect"
-	"unsafe"
 )
 
 type Selector[T any] struct {
 	builder
-	tbl   string
+	table TableReference
 	where []Predicate
 
-	db *DB
+	sess Session
 
 	columns []Selectable
+	groupBy []Column
+	having  []Predicate
+	orderBy []OrderBy
+	limit   int
+	offset  int
+
+	allowFullTable bool
 }
 
 func (s *Selector[T]) Select(cols ...Selectable) *Selector[T] {
@@ -28,82 +32,135 @@ func (s *Selector[T]) Where(ps ...Predicate) *Selector[T] {
 	return s
 }
 
-func (s *Selector[T]) From(tbl string) *Selector[T] {
-	s.tbl = tbl
+// From 不调用或者传入 nil 表示使用 T 对应的表
+func (s *Selector[T]) From(table TableReference) *Selector[T] {
+	s.table = table
+	return s
+}
+
+// AsSubquery 把 Selector 作为子查询使用，alias 在 FROM 和 JOIN 中是必须的
+func (s *Selector[T]) AsSubquery(alias string) Subquery {
+	table := s.table
+	if table == nil {
+		table = TableOf(new(T))
+	}
+	return Subquery{
+		s:       s,
+		columns: s.columns,
+		alias:   alias,
+		table:   table,
+	}
+}
+
+func (s *Selector[T]) GroupBy(cols ...Column) *Selector[T] {
+	s.groupBy = cols
 	return s
 }
 
-func NewSelector[T any](db *DB) *Selector[T] {
+// Having 多个 Predicate 之间用 AND 连接，可以使用聚合函数，例如 Having(Count("Id").Gt(10))
+func (s *Selector[T]) Having(ps ...Predicate) *Selector[T] {
+	s.having = ps
+	return s
+}
+
+// OrderBy 按照传入的顺序排序，例如 OrderBy(Asc("Age"), Desc("Id"))
+func (s *Selector[T]) OrderBy(orders ...OrderBy) *Selector[T] {
+	s.orderBy = orders
+	return s
+}
+
+// Limit 小于等于 0 表示不限制
+func (s *Selector[T]) Limit(limit int) *Selector[T] {
+	s.limit = limit
+	return s
+}
+
+func (s *Selector[T]) Offset(offset int) *Selector[T] {
+	s.offset = offset
+	return s
+}
+
+// AllowFullTable 没有 LIMIT 也可以读取大表，用于绕过 safety 之类的 Middleware
+func (s *Selector[T]) AllowFullTable() *Selector[T] {
+	s.allowFullTable = true
+	return s
+}
+
+func (s *Selector[T]) IsFullTable() bool {
+	return !s.allowFullTable && s.limit <= 0
+}
+
+func NewSelector[T any](sess Session) *Selector[T] {
 	return &Selector[T]{
-		db: db,
+		sess: sess,
 		builder: builder{
-			dialect: db.dialect,
+			core: sess.getCore(),
 		},
 	}
 }
 
+// Build 每次都重新构造，同一个 Selector 作为子查询可以使用多次
 func (s *Selector[T]) Build() (*Query, error) {
+	s.sb.Reset()
+	s.args = nil
+	s.from = s.table
 	t := new(T)
 	var err error
-	s.model, err = s.db.r.Get(t)
+	s.model, err = s.r.Get(t)
 	if err != nil {
 		return nil, err
 	}
 	s.sb.WriteString("SELECT ")
-	if len(s.columns) == 0 {
-		s.sb.WriteString("*")
-	} else {
-		for i, c := range s.columns {
-			switch col := c.(type) {
-			case Column:
-				fd, ok := s.model.FieldMap[col.name]
-				if !ok {
-					return nil, errs.NewErrUnKnowField(col.name)
-				}
-				if i > 0 {
-					s.sb.WriteByte(',')
-				}
-				s.quote(fd.ColName)
-			case Aggregate:
-				fd, ok := s.model.FieldMap[col.arg]
-				if !ok {
-					return nil, errs.NewErrUnKnowField(col.arg)
-				}
-				if i > 0 {
-					s.sb.WriteByte(',')
-				}
-				s.sb.WriteString(col.fn)
-				s.sb.WriteByte('(')
-				s.quote(fd.ColName)
-				s.sb.WriteByte(')')
-			case RawExpr:
-				s.sb.WriteString(col.raw)
-				if len(col.args) > 0 {
-					s.args = append(s.args, col.args...)
-				}
-			}
-		}
+	if err = s.buildColumns(); err != nil {
+		return nil, err
 	}
 	s.sb.WriteString(" FROM ")
-
-	if s.tbl == "" {
-		s.quote(s.model.TableName)
-	} else {
-		s.sb.WriteString(s.tbl)
+	if err = s.buildTable(s.table); err != nil {
+		return nil, err
 	}
 
 	if len(s.where) > 0 {
 		s.sb.WriteString(" WHERE ")
-		pred := s.where[0]
-		for i := 1; i < len(s.where); i++ {
-			pred = pred.And(s.where[i])
+		if err = s.buildPredicates(s.where); err != nil {
+			return nil, err
 		}
-		err := s.buildExpression(pred)
-		if err != nil {
+	}
+
+	if len(s.groupBy) > 0 {
+		s.sb.WriteString(" GROUP BY ")
+		for i, col := range s.groupBy {
+			if i > 0 {
+				s.sb.WriteByte(',')
+			}
+			if err = s.buildExpression(col); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(s.having) > 0 {
+		s.sb.WriteString(" HAVING ")
+		if err = s.buildPredicates(s.having); err != nil {
 			return nil, err
 		}
 	}
 
+	if len(s.orderBy) > 0 {
+		s.sb.WriteString(" ORDER BY ")
+		for i, ob := range s.orderBy {
+			if i > 0 {
+				s.sb.WriteByte(',')
+			}
+			if err = s.buildFromColumn(ob.col); err != nil {
+				return nil, err
+			}
+			s.sb.WriteByte(' ')
+			s.sb.WriteString(ob.order)
+		}
+	}
+
+	s.dialect.LimitOffset(s.writer(), s.limit, s.offset)
+
 	s.sb.WriteByte(';')
 	return &Query{
 		SQL:  s.sb.String(),
@@ -111,122 +168,154 @@ func (s *Selector[T]) Build() (*Query, error) {
 	}, nil
 }
 
-func (s *Selector[T]) buildExpression(expression Expression) error {
-	switch expr := expression.(type) {
-	case nil:
+func (s *Selector[T]) buildColumns() error {
+	if len(s.columns) == 0 {
+		s.sb.WriteByte('*')
 		return nil
-	case Value:
-		s.sb.WriteByte('?')
-		if s.args == nil {
-			s.args = make([]any, 0, 8)
-		}
-		s.args = append(s.args, expr.val)
-	case Column:
-		fd, ok := s.model.FieldMap[expr.name]
-		if !ok {
-			return errs.NewErrUnKnowField(expr.name)
-		}
-		s.quote(fd.ColName)
-	case Predicate:
-		P, ok := expr.left.(Predicate)
-		if ok && P.op != opNOT {
-			s.sb.WriteByte('(')
-		}
-		if err := s.buildExpression(expr.left); err != nil {
-			return err
+	}
+	for i, c := range s.columns {
+		if i > 0 {
+			s.sb.WriteByte(',')
 		}
-		if ok && P.op != opNOT {
-			s.sb.WriteByte(')')
+		switch col := c.(type) {
+		case Column:
+			if err := s.buildFromColumn(col); err != nil {
+				return err
+			}
+			s.buildAs(col.alias)
+		case Aggregate:
+			if err := s.buildAggregate(col); err != nil {
+				return err
+			}
+			s.buildAs(col.alias)
+		case MathExpr:
+			if err := s.buildExpression(col); err != nil {
+				return err
+			}
+			s.buildAs(col.alias)
+		case FuncExpr:
+			if err := s.buildExpression(col); err != nil {
+				return err
+			}
+			s.buildAs(col.alias)
+		case RawExpr:
+			s.buildRaw(col)
 		}
+	}
+	return nil
+}
 
-		if expr.op != opNOT && expr.op != "" {
-			s.sb.WriteByte(' ')
-
-		}
-		s.sb.WriteString(expr.op.String())
-		if expr.op != "" {
-			s.sb.WriteByte(' ')
+func (s *Selector[T]) buildTable(table TableReference) error {
+	switch tab := table.(type) {
+	case nil:
+		s.quoteTable(s.model)
+	case Table:
+		m, err := s.r.Get(tab.entity)
+		if err != nil {
+			return err
 		}
-
-		_, ok = expr.right.(Predicate)
-		if ok {
-			s.sb.WriteByte('(')
+		s.quoteTable(m)
+		s.buildAs(tab.alias)
+	case Join:
+		s.sb.WriteByte('(')
+		if err := s.buildTable(tab.left); err != nil {
+			return err
 		}
-		if err := s.buildExpression(expr.right); err != nil {
+		s.sb.WriteByte(' ')
+		s.sb.WriteString(tab.typ)
+		s.sb.WriteByte(' ')
+		if err := s.buildTable(tab.right); err != nil {
 			return err
 		}
-		if ok {
+		if len(tab.using) > 0 {
+			s.sb.WriteString(" USING (")
+			for i, col := range tab.using {
+				if i > 0 {
+					s.sb.WriteByte(',')
+				}
+				colName, err := s.colName(tab, col)
+				if err != nil {
+					return err
+				}
+				s.quote(colName)
+			}
 			s.sb.WriteByte(')')
 		}
+		if len(tab.on) > 0 {
+			s.sb.WriteString(" ON ")
+			if err := s.buildPredicates(tab.on); err != nil {
+				return err
+			}
+		}
+		s.sb.WriteByte(')')
+	case Subquery:
+		return s.buildSubquery(tab, true)
 	case RawExpr:
-		s.sb.WriteString(expr.raw)
-		s.args = append(s.args, expr.args...)
+		s.buildRaw(tab)
 	default:
-		return errors.New("orm: 不支持的表达式")
+		return errs.NewErrUnsupportedTable(table)
 	}
 	return nil
 }
 
 func (s *Selector[T]) Get(ctx context.Context) (*T, error) {
-	q, err := s.Build()
+	qr := s.core.handle(ctx, newQueryContext[T](s.core, "SELECT", s), s.get)
+	return resultOf[*T](qr)
+}
+
+func (s *Selector[T]) get(ctx context.Context, qc *QueryContext) *QueryResult {
+	q, err := qc.Query()
 	if err != nil {
-		return nil, err
+		return &QueryResult{Err: err}
 	}
 
-	rows, err := s.db.db.QueryContext(ctx, q.SQL, q.Args...)
+	rows, err := s.sess.queryContext(ctx, q.SQL, q.Args...)
 	if err != nil {
-		return nil, err
+		return &QueryResult{Err: err}
 	}
+	defer func() { _ = rows.Close() }()
 
 	t := new(T)
 
-	val := s.db.valCreator(t, s.model)
-
-	return t, val.SetColumns(rows)
+	val := s.valCreator(t, s.model)
+	if err = val.SetColumns(rows); err != nil {
+		return &QueryResult{Err: err}
+	}
+	return &QueryResult{Result: t}
 }
 
 func (s *Selector[T]) GetMulti(ctx context.Context) ([]*T, error) {
-	q, err := s.Build()
-	if err != nil {
-		return nil, err
-	}
-
-	rows, err := s.db.db.QueryContext(ctx, q.SQL, q.Args...)
-	if err != nil {
-		return nil, err
-	}
-
-	ret := make([]*T, 0, 64)
-
-	for rows.Next() {
-		t := new(T)
-		ret = append(ret, t)
+	qr := s.core.handle(ctx, newQueryContext[T](s.core, "SELECT", s), func(ctx context.Context, qc *QueryContext) *QueryResult {
+		return getMulti[T](ctx, s.sess, qc)
+	})
+	return resultOf[[]*T](qr)
+}
 
-		cols, err := rows.Columns()
-		if err != nil {
-			return nil, err
-		}
-		// 校验列数
-		if len(cols) > len(s.model.ColumnMap) {
-			return nil, errs.ErrTooManyColumns
-		}
+// OrderBy 列没有指定表的时候在 FROM 的表中查找
+type OrderBy struct {
+	col   Column
+	order string
+}
 
-		vals := make([]any, 0, len(cols))
-		for _, col := range cols {
-			fd, ok := s.model.ColumnMap[col]
-			if !ok {
-				return nil, errs.NewErrUnKnowColumn(col)
-			}
+func Asc(col string) OrderBy {
+	return C(col).Asc()
+}
 
-			// 计算字段的真实地址：对象起始地址 + 字段偏移量
-			addr := unsafe.Pointer(reflect.ValueOf(t).Pointer())
-			fdVal := reflect.NewAt(fd.Typ, unsafe.Pointer(uintptr(addr)+fd.Offset))
-			// Scan 需要指针不需要调用 Elem
-			vals = append(vals, fdVal.Interface())
-		}
+func Desc(col string) OrderBy {
+	return C(col).Desc()
+}
 
-		rows.Scan(vals...)
+// Asc 指定了表的列的排序，例如 TableOf(&Order{}).C("Amount").Asc()
+func (c Column) Asc() OrderBy {
+	return OrderBy{
+		col:   c,
+		order: "ASC",
 	}
+}
 
-	return ret, nil
+func (c Column) Desc() OrderBy {
+	return OrderBy{
+		col:   c,
+		order: "DESC",
+	}
 }
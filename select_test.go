@@ -3,6 +3,7 @@ package morm
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/DATA-DOG/go-sqlmock"
 	_ "github.com/mattn/go-sqlite3"
@@ -23,7 +24,7 @@ func TestSelector_Build(t *testing.T) {
 	}{
 		{
 			name: "From",
-			s:    NewSelector[TestModel](db).From("`test_sql_model`"),
+			s:    NewSelector[TestModel](db).From(Raw("`test_sql_model`")),
 			wantQuery: &Query{
 				SQL:  "SELECT * FROM `test_sql_model`;",
 				Args: nil,
@@ -41,7 +42,7 @@ func TestSelector_Build(t *testing.T) {
 		},
 		{
 			name: "null From",
-			s:    NewSelector[TestModel](db).From(""),
+			s:    NewSelector[TestModel](db).From(nil),
 			wantQuery: &Query{
 				SQL:  "SELECT * FROM `test_model`;",
 				Args: nil,
@@ -50,7 +51,7 @@ func TestSelector_Build(t *testing.T) {
 		},
 		{
 			name: "with db",
-			s:    NewSelector[TestModel](db).From("`test_db`.`test_model`"),
+			s:    NewSelector[TestModel](db).From(Raw("`test_db`.`test_model`")),
 			wantQuery: &Query{
 				SQL:  "SELECT * FROM `test_db`.`test_model`;",
 				Args: nil,
@@ -210,15 +211,12 @@ func TestSelector_Get(t *testing.T) {
 }
 
 func TestSelector_GetMulti(t *testing.T) {
-	mockDB, mock, err := sqlmock.New()
-	require.NoError(t, err)
-	defer func() { _ = mockDB.Close() }()
-
+	rowErr := errors.New("row error")
 	tests := []struct {
 		name     string
 		query    string
 		mockErr  error
-		mockRows *sqlmock.Rows
+		mockRows func() *sqlmock.Rows
 		wantErr  error
 		wantVal  []*TestModel
 	}{
@@ -232,7 +230,7 @@ func TestSelector_GetMulti(t *testing.T) {
 				rows.AddRow([]byte("2"), []byte("ma"), []byte("18"), []byte("jun"))
 				rows.AddRow([]byte("3"), []byte("lao"), []byte("18"), []byte("zhang"))
 				return rows
-			}(),
+			},
 			wantVal: []*TestModel{
 				{
 					Id:        1,
@@ -254,26 +252,141 @@ func TestSelector_GetMulti(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "no rows",
+			query: "SELECT .*",
+			mockRows: func() *sqlmock.Rows {
+				return sqlmock.NewRows([]string{"id", "first_name", "age", "last_name"})
+			},
+			wantVal: []*TestModel{},
+		},
+		{
+			name:    "query error",
+			query:   "SELECT .*",
+			mockErr: rowErr,
+			wantErr: rowErr,
+		},
+		{
+			name:  "invalid col",
+			query: "SELECT .*",
+			mockRows: func() *sqlmock.Rows {
+				rows := sqlmock.NewRows([]string{"id", "gender"})
+				rows.AddRow([]byte("1"), []byte("man"))
+				return rows
+			},
+			wantErr: errs.NewErrUnKnowColumn("gender"),
+		},
+		{
+			name:  "scan error",
+			query: "SELECT .*",
+			mockRows: func() *sqlmock.Rows {
+				rows := sqlmock.NewRows([]string{"id"})
+				rows.AddRow([]byte("abc"))
+				return rows
+			},
+			wantErr: errors.New("sql: Scan error on column index 0, name \"id\": " +
+				"converting driver.Value type []uint8 (\"abc\") to a int64: invalid syntax"),
+		},
+		{
+			name:  "row error",
+			query: "SELECT .*",
+			mockRows: func() *sqlmock.Rows {
+				rows := sqlmock.NewRows([]string{"id"})
+				rows.AddRow([]byte("1"))
+				rows.AddRow([]byte("2"))
+				rows.RowError(1, rowErr)
+				return rows
+			},
+			wantErr: rowErr,
+		},
 	}
-	for _, tc := range tests {
-		if tc.mockErr != nil {
-			mock.ExpectQuery(tc.query).WillReturnError(tc.mockErr)
-		} else {
-			mock.ExpectQuery(tc.query).WillReturnRows(tc.mockRows)
+
+	for _, opt := range []DBOption{DBUseReflectValuer(), func(db *DB) {}} {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockDB, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				defer func() { _ = mockDB.Close() }()
+				if tt.mockErr != nil {
+					mock.ExpectQuery(tt.query).WillReturnError(tt.mockErr)
+				} else {
+					mock.ExpectQuery(tt.query).WillReturnRows(tt.mockRows()).RowsWillBeClosed()
+				}
+
+				db, err := OpenDB(mockDB, opt)
+				require.NoError(t, err)
+				res, err := NewSelector[TestModel](db).GetMulti(context.Background())
+				if tt.wantErr != nil {
+					require.Error(t, err)
+					assert.Equal(t, tt.wantErr.Error(), err.Error())
+					return
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantVal, res)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			})
 		}
 	}
+}
 
-	db, err := OpenDB(mockDB, DBUseReflectValuer())
-	require.NoError(t, err)
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			res, err := NewSelector[TestModel](db).GetMulti(context.Background())
-			assert.Equal(t, tt.wantErr, err)
-			if err != nil {
-				return
-			}
-			assert.Equal(t, tt.wantVal, res)
-		})
+type unexportedBase struct {
+	Id int64
+}
+
+type UnexportedEmbeddedModel struct {
+	*unexportedBase
+	Name string
+}
+
+func TestSelector_GetEmbedded(t *testing.T) {
+	for _, opt := range []DBOption{DBUseReflectValuer(), func(db *DB) {}} {
+		mockDB, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		db, err := OpenDB(mockDB, opt)
+		require.NoError(t, err)
+
+		rows := sqlmock.NewRows([]string{"id", "create_time", "name", "home_city", "home_street"})
+		rows.AddRow([]byte("1"), []byte("100"), []byte("xiao"), []byte("sz"), []byte("nanshan"))
+		mock.ExpectQuery("SELECT \\* FROM `embedded_model`;").WillReturnRows(rows)
+		res, err := NewSelector[EmbeddedModel](db).Get(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, &EmbeddedModel{
+			Base: Base{Id: 1, CreateTime: 100},
+			Name: "xiao",
+			Home: Address{City: "sz", Street: "nanshan"},
+		}, res)
+
+		// 嵌入的指针为 nil 的时候会自动创建
+		rows = sqlmock.NewRows([]string{"name", "id", "create_time"})
+		rows.AddRow([]byte("xiao"), []byte("1"), []byte("100"))
+		rows.AddRow([]byte("ma"), []byte("2"), []byte("200"))
+		mock.ExpectQuery("SELECT \\* FROM `embedded_ptr_model`;").WillReturnRows(rows)
+		ptrRes, err := NewSelector[EmbeddedPtrModel](db).GetMulti(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []*EmbeddedPtrModel{
+			{Name: "xiao", Base: &Base{Id: 1, CreateTime: 100}},
+			{Name: "ma", Base: &Base{Id: 2, CreateTime: 200}},
+		}, ptrRes)
+
+		// 同一个结构体嵌入两次
+		rows = sqlmock.NewRows([]string{"id", "home_city", "work_city"})
+		rows.AddRow([]byte("1"), []byte("sz"), []byte("gz"))
+		mock.ExpectQuery("SELECT \\* FROM `two_address_model` WHERE `work_city` = \\?;").
+			WithArgs("gz").WillReturnRows(rows)
+		twoRes, err := NewSelector[TwoAddressModel](db).Where(C("Work.City").Eq("gz")).Get(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, &TwoAddressModel{Id: 1, Home: Address{City: "sz"}, Work: Address{City: "gz"}}, twoRes)
+
+		// 没有导出的嵌入结构体指针
+		rows = sqlmock.NewRows([]string{"id", "name"})
+		rows.AddRow([]byte("1"), []byte("xiao"))
+		mock.ExpectQuery("SELECT \\* FROM `unexported_embedded_model`;").WillReturnRows(rows)
+		unexportedRes, err := NewSelector[UnexportedEmbeddedModel](db).Get(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, &UnexportedEmbeddedModel{unexportedBase: &unexportedBase{Id: 1}, Name: "xiao"}, unexportedRes)
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+		_ = mockDB.Close()
 	}
 }
 
@@ -336,6 +449,785 @@ func TestSelector_Select(t *testing.T) {
 	}
 }
 
+func TestSelector_OrderByLimit(t *testing.T) {
+	db := memoryDB(t)
+	sqliteDB, err := OpenDB(db.db, DBWithDialect(&sqliteDialect{}))
+	require.NoError(t, err)
+	tests := []struct {
+		name      string
+		s         QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "order by",
+			s:    NewSelector[TestModel](db).OrderBy(Asc("Age"), Desc("Id")),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` ORDER BY `age` ASC,`id` DESC;",
+			},
+		},
+		{
+			name:    "order by unknown field",
+			s:       NewSelector[TestModel](db).OrderBy(Asc("age")),
+			wantErr: errs.NewErrUnKnowField("age"),
+		},
+		{
+			name: "limit offset",
+			s: NewSelector[TestModel](db).Where(C("Age").Gt(18)).
+				OrderBy(Desc("Id")).Limit(10).Offset(20),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `age` > ? ORDER BY `id` DESC LIMIT ? OFFSET ?;",
+				Args: []any{18, 10, 20},
+			},
+		},
+		{
+			name: "limit",
+			s:    NewSelector[TestModel](db).Limit(10),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` LIMIT ?;",
+				Args: []any{10},
+			},
+		},
+		{
+			name: "offset only",
+			s:    NewSelector[TestModel](db).Offset(20),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` LIMIT 18446744073709551615 OFFSET ?;",
+				Args: []any{20},
+			},
+		},
+		{
+			name: "sqlite offset only",
+			s:    NewSelector[TestModel](sqliteDB).Offset(20),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` LIMIT -1 OFFSET ?;",
+				Args: []any{20},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := test.s.Build()
+			assert.Equal(t, test.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, test.wantQuery, q)
+		})
+	}
+}
+
+func TestSelector_GroupByHaving(t *testing.T) {
+	db := memoryDB(t)
+	tests := []struct {
+		name      string
+		s         QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "group by",
+			s:    NewSelector[TestModel](db).Select(C("Age"), Count("Id")).GroupBy(C("Age")),
+			wantQuery: &Query{
+				SQL: "SELECT `age`,COUNT(`id`) FROM `test_model` GROUP BY `age`;",
+			},
+		},
+		{
+			name: "group by multiple columns",
+			s:    NewSelector[TestModel](db).GroupBy(C("Age"), C("FirstName")),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` GROUP BY `age`,`first_name`;",
+			},
+		},
+		{
+			name:    "group by unknown field",
+			s:       NewSelector[TestModel](db).GroupBy(C("age")),
+			wantErr: errs.NewErrUnKnowField("age"),
+		},
+		{
+			name: "having",
+			s: NewSelector[TestModel](db).Select(C("Age"), Count("Id")).
+				Where(C("Id").Gt(1)).GroupBy(C("Age")).
+				Having(Count("Id").Gt(10), Avg("Id").Lt(100)),
+			wantQuery: &Query{
+				SQL: "SELECT `age`,COUNT(`id`) FROM `test_model` WHERE `id` > ? GROUP BY `age`" +
+					" HAVING (COUNT(`id`) > ?) AND (AVG(`id`) < ?);",
+				Args: []any{1, 10, 100},
+			},
+		},
+		{
+			name: "having comparisons",
+			s: NewSelector[TestModel](db).GroupBy(C("Age")).
+				Having(Count("Id").GtEq(10), Sum("Id").NotEq(0), Max("Id").LtEq(100)),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` GROUP BY `age`" +
+					" HAVING ((COUNT(`id`) >= ?) AND (SUM(`id`) != ?)) AND (MAX(`id`) <= ?);",
+				Args: []any{10, 0, 100},
+			},
+		},
+		{
+			name: "having column",
+			s: NewSelector[TestModel](db).GroupBy(C("Age")).
+				Having(C("Age").Eq(18)).OrderBy(Asc("Age")),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` GROUP BY `age` HAVING `age` = ? ORDER BY `age` ASC;",
+				Args: []any{18},
+			},
+		},
+		{
+			name:    "having unknown field",
+			s:       NewSelector[TestModel](db).GroupBy(C("Age")).Having(Sum("age").Gt(1)),
+			wantErr: errs.NewErrUnKnowField("age"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := test.s.Build()
+			assert.Equal(t, test.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, test.wantQuery, q)
+		})
+	}
+}
+
+func TestSelector_Alias(t *testing.T) {
+	db := memoryDB(t)
+	tests := []struct {
+		name      string
+		s         QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "column alias",
+			s:    NewSelector[TestModel](db).Select(C("Id").As("my_id"), C("Age")),
+			wantQuery: &Query{
+				SQL: "SELECT `id` AS `my_id`,`age` FROM `test_model`;",
+			},
+		},
+		{
+			name: "aggregate alias",
+			s:    NewSelector[TestModel](db).Select(Avg("Age").As("avg_age"), Count("Id")),
+			wantQuery: &Query{
+				SQL: "SELECT AVG(`age`) AS `avg_age`,COUNT(`id`) FROM `test_model`;",
+			},
+		},
+		{
+			name: "alias ignored in where",
+			s:    NewSelector[TestModel](db).Select(C("Age").As("a")).Where(C("Age").As("a").Gt(18)),
+			wantQuery: &Query{
+				SQL:  "SELECT `age` AS `a` FROM `test_model` WHERE `age` > ?;",
+				Args: []any{18},
+			},
+		},
+		{
+			name: "raw and column",
+			s:    NewSelector[TestModel](db).Select(Raw("DISTINCT `first_name`"), C("Age")),
+			wantQuery: &Query{
+				SQL: "SELECT DISTINCT `first_name`,`age` FROM `test_model`;",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := test.s.Build()
+			assert.Equal(t, test.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, test.wantQuery, q)
+		})
+	}
+}
+
+func TestSelector_GetAlias(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        func(db *DB) *Selector[TestModel]
+		mockRows func() *sqlmock.Rows
+		wantErr  error
+		wantVal  *TestModel
+	}{
+		{
+			name: "alias matches column",
+			s: func(db *DB) *Selector[TestModel] {
+				return NewSelector[TestModel](db).Select(Max("Age").As("age"))
+			},
+			mockRows: func() *sqlmock.Rows {
+				return sqlmock.NewRows([]string{"age"}).AddRow([]byte("35"))
+			},
+			wantVal: &TestModel{Age: 35},
+		},
+		{
+			name: "alias matches field",
+			s: func(db *DB) *Selector[TestModel] {
+				return NewSelector[TestModel](db).Select(Count("Id").As("Id"))
+			},
+			mockRows: func() *sqlmock.Rows {
+				return sqlmock.NewRows([]string{"Id"}).AddRow([]byte("12"))
+			},
+			wantVal: &TestModel{Id: 12},
+		},
+		{
+			name: "unknown alias",
+			s: func(db *DB) *Selector[TestModel] {
+				return NewSelector[TestModel](db).Select(Avg("Age").As("avg_age"))
+			},
+			mockRows: func() *sqlmock.Rows {
+				return sqlmock.NewRows([]string{"avg_age"}).AddRow([]byte("35"))
+			},
+			wantErr: errs.NewErrUnKnowColumn("avg_age"),
+		},
+	}
+
+	for _, opt := range []DBOption{DBUseReflectValuer(), func(db *DB) {}} {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockDB, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				defer func() { _ = mockDB.Close() }()
+				mock.ExpectQuery("SELECT .* AS .*").WillReturnRows(tt.mockRows())
+
+				db, err := OpenDB(mockDB, opt)
+				require.NoError(t, err)
+				res, err := tt.s(db).Get(context.Background())
+				assert.Equal(t, tt.wantErr, err)
+				if err != nil {
+					return
+				}
+				assert.Equal(t, tt.wantVal, res)
+			})
+		}
+	}
+}
+
+func TestSelector_Join(t *testing.T) {
+	db := memoryDB(t)
+	type Order struct {
+		Id        int
+		UsingCol1 string
+		UsingCol2 string
+	}
+
+	type OrderDetail struct {
+		OrderId   int
+		ItemId    int
+		UsingCol1 string
+		UsingCol2 string
+	}
+
+	type Item struct {
+		Id int
+	}
+
+	tests := []struct {
+		name      string
+		s         QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "specify table",
+			s:    NewSelector[Order](db).From(TableOf(&OrderDetail{})),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `order_detail`;",
+			},
+		},
+		{
+			name: "table alias",
+			s: NewSelector[Order](db).From(TableOf(&Order{}).As("o")).
+				Where(TableOf(&Order{}).As("o").C("Id").Eq(1)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `order` AS `o` WHERE `o`.`id` = ?;",
+				Args: []any{1},
+			},
+		},
+		{
+			// 没有指定表的列在 Join 右边的表中找到
+			name: "join order by and aggregate",
+			s: func() QueryBuilder {
+				t1 := TableOf(&Order{}).As("t1")
+				t2 := TableOf(&OrderDetail{}).As("t2")
+				return NewSelector[Order](db).Select(t1.C("Id"), Count("ItemId"), t2.C("OrderId").Max().As("max_id")).
+					From(t1.Join(t2).On(t1.C("Id").Eq(t2.C("OrderId")))).
+					GroupBy(t1.C("Id")).Having(t2.C("ItemId").Count().Gt(1)).
+					OrderBy(Asc("ItemId"), t1.C("Id").Desc())
+			}(),
+			wantQuery: &Query{
+				SQL: "SELECT `t1`.`id`,COUNT(`item_id`),MAX(`t2`.`order_id`) AS `max_id` FROM " +
+					"(`order` AS `t1` JOIN `order_detail` AS `t2` ON `t1`.`id` = `t2`.`order_id`) " +
+					"GROUP BY `t1`.`id` HAVING COUNT(`t2`.`item_id`) > ? ORDER BY `item_id` ASC,`t1`.`id` DESC;",
+				Args: []any{1},
+			},
+		},
+		{
+			// GROUP BY、HAVING 和 WHERE 中没有指定表的列和 ORDER BY 一样在 Join 的表中查找
+			name: "join group by and having",
+			s: func() QueryBuilder {
+				t1 := TableOf(&Order{}).As("t1")
+				t2 := TableOf(&OrderDetail{}).As("t2")
+				return NewSelector[Order](db).Select(C("ItemId"), Count("OrderId")).
+					From(t1.Join(t2).On(t1.C("Id").Eq(t2.C("OrderId")))).
+					Where(C("ItemId").Gt(0)).GroupBy(C("ItemId")).
+					Having(C("ItemId").Lt(100), Count("OrderId").Gt(1)).OrderBy(Asc("ItemId"))
+			}(),
+			wantQuery: &Query{
+				SQL: "SELECT `item_id`,COUNT(`order_id`) FROM " +
+					"(`order` AS `t1` JOIN `order_detail` AS `t2` ON `t1`.`id` = `t2`.`order_id`) " +
+					"WHERE `item_id` > ? GROUP BY `item_id` HAVING (`item_id` < ?) AND (COUNT(`order_id`) > ?) " +
+					"ORDER BY `item_id` ASC;",
+				Args: []any{0, 100, 1},
+			},
+		},
+		{
+			name: "join order by unknown field",
+			s: func() QueryBuilder {
+				t1 := TableOf(&Order{})
+				t2 := TableOf(&Item{})
+				return NewSelector[Order](db).From(t1.Join(t2).On(t1.C("Id").Eq(t2.C("Id")))).
+					OrderBy(Asc("ItemId"))
+			}(),
+			wantErr: errs.NewErrUnKnowField("ItemId"),
+		},
+		{
+			name: "join using",
+			s: func() QueryBuilder {
+				t1 := TableOf(&Order{})
+				t2 := TableOf(&OrderDetail{})
+				return NewSelector[Order](db).
+					From(t1.Join(t2).Using("UsingCol1", "UsingCol2"))
+			}(),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM (`order` JOIN `order_detail` USING (`using_col1`,`using_col2`));",
+			},
+		},
+		{
+			name: "left join on",
+			s: func() QueryBuilder {
+				t1 := TableOf(&Order{}).As("t1")
+				t2 := TableOf(&OrderDetail{}).As("t2")
+				return NewSelector[Order](db).
+					Select(t1.C("Id"), t2.C("ItemId").As("item")).
+					From(t1.LeftJoin(t2).On(t1.C("Id").Eq(t2.C("OrderId")))).
+					Where(t2.C("ItemId").Gt(10))
+			}(),
+			wantQuery: &Query{
+				SQL: "SELECT `t1`.`id`,`t2`.`item_id` AS `item` FROM " +
+					"(`order` AS `t1` LEFT JOIN `order_detail` AS `t2` ON `t1`.`id` = `t2`.`order_id`) " +
+					"WHERE `t2`.`item_id` > ?;",
+				Args: []any{10},
+			},
+		},
+		{
+			name: "join join",
+			s: func() QueryBuilder {
+				t1 := TableOf(&Order{}).As("t1")
+				t2 := TableOf(&OrderDetail{}).As("t2")
+				t3 := TableOf(&Item{}).As("t3")
+				j := t1.Join(t2).On(t1.C("Id").Eq(t2.C("OrderId")))
+				return NewSelector[Order](db).
+					From(j.RightJoin(t3).On(t2.C("ItemId").Eq(t3.C("Id"))))
+			}(),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM ((`order` AS `t1` JOIN `order_detail` AS `t2` ON `t1`.`id` = `t2`.`order_id`) " +
+					"RIGHT JOIN `item` AS `t3` ON `t2`.`item_id` = `t3`.`id`);",
+			},
+		},
+		{
+			name: "table join join",
+			s: func() QueryBuilder {
+				t1 := TableOf(&Order{}).As("t1")
+				t2 := TableOf(&OrderDetail{}).As("t2")
+				t3 := TableOf(&Item{}).As("t3")
+				j := t2.Join(t3).On(t2.C("ItemId").Eq(t3.C("Id")))
+				return NewSelector[Order](db).
+					From(t1.Join(j).On(t1.C("Id").Eq(t2.C("OrderId"))))
+			}(),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM (`order` AS `t1` JOIN " +
+					"(`order_detail` AS `t2` JOIN `item` AS `t3` ON `t2`.`item_id` = `t3`.`id`) " +
+					"ON `t1`.`id` = `t2`.`order_id`);",
+			},
+		},
+		{
+			name: "join unknown field",
+			s: func() QueryBuilder {
+				t1 := TableOf(&Order{}).As("t1")
+				t2 := TableOf(&OrderDetail{}).As("t2")
+				return NewSelector[Order](db).
+					From(t1.Join(t2).On(t1.C("Id").Eq(t2.C("Invalid"))))
+			}(),
+			wantErr: errs.NewErrUnKnowField("Invalid"),
+		},
+		{
+			name: "using unknown field",
+			s: func() QueryBuilder {
+				t1 := TableOf(&Order{})
+				t2 := TableOf(&Item{})
+				return NewSelector[Order](db).From(t1.Join(t2).Using("Invalid"))
+			}(),
+			wantErr: errs.NewErrUnKnowField("Invalid"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := test.s.Build()
+			assert.Equal(t, test.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, test.wantQuery, q)
+		})
+	}
+}
+
+func TestSelector_Subquery(t *testing.T) {
+	db := memoryDB(t)
+	type Order struct {
+		Id     int
+		UserId int
+	}
+
+	type OrderDetail struct {
+		OrderId int
+		ItemId  int
+	}
+
+	tests := []struct {
+		name      string
+		s         QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "from",
+			s: func() QueryBuilder {
+				sub := NewSelector[OrderDetail](db).Where(C("ItemId").Gt(10)).AsSubquery("sub")
+				return NewSelector[Order](db).From(sub)
+			}(),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM (SELECT * FROM `order_detail` WHERE `item_id` > ?) AS `sub`;",
+				Args: []any{10},
+			},
+		},
+		{
+			name: "in",
+			s: func() QueryBuilder {
+				sub := NewSelector[Order](db).Select(C("UserId")).Where(C("Id").Gt(100)).AsSubquery("sub")
+				return NewSelector[TestModel](db).Where(C("Age").Gt(18), C("Id").InQuery(sub))
+			}(),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` WHERE (`age` > ?) AND " +
+					"(`id` IN (SELECT `user_id` FROM `order` WHERE `id` > ?));",
+				Args: []any{18, 100},
+			},
+		},
+		{
+			name: "in subquery",
+			s: func() QueryBuilder {
+				sub := NewSelector[Order](db).Select(C("UserId")).Where(C("Id").Gt(100)).AsSubquery("sub")
+				return NewSelector[TestModel](db).Where(C("Id").In(sub), C("Age").NotIn(sub))
+			}(),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` WHERE (`id` IN (SELECT `user_id` FROM `order` WHERE `id` > ?)) AND " +
+					"(`age` NOT IN (SELECT `user_id` FROM `order` WHERE `id` > ?));",
+				Args: []any{100, 100},
+			},
+		},
+		{
+			name: "exists",
+			s: func() QueryBuilder {
+				sub := NewSelector[Order](db).Where(C("UserId").Eq(12)).AsSubquery("sub")
+				return NewSelector[TestModel](db).Where(Exists(sub))
+			}(),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE EXISTS (SELECT * FROM `order` WHERE `user_id` = ?);",
+				Args: []any{12},
+			},
+		},
+		{
+			name: "not exists",
+			s: func() QueryBuilder {
+				sub := NewSelector[Order](db).AsSubquery("sub")
+				return NewSelector[TestModel](db).Where(NotExists(sub))
+			}(),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` WHERE NOT EXISTS (SELECT * FROM `order`);",
+			},
+		},
+		{
+			name: "any all",
+			s: func() QueryBuilder {
+				sub1 := NewSelector[Order](db).Select(C("UserId")).AsSubquery("sub1")
+				sub2 := NewSelector[Order](db).Select(C("UserId")).Where(C("Id").Lt(5)).AsSubquery("sub2")
+				return NewSelector[TestModel](db).Where(C("Id").Gt(Any(sub1)), C("Id").Lt(All(sub2)))
+			}(),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` WHERE (`id` > ANY (SELECT `user_id` FROM `order`)) AND " +
+					"(`id` < ALL (SELECT `user_id` FROM `order` WHERE `id` < ?));",
+				Args: []any{5},
+			},
+		},
+		{
+			name: "join subquery",
+			s: func() QueryBuilder {
+				t1 := TableOf(&Order{}).As("t1")
+				sub := NewSelector[OrderDetail](db).Select(C("OrderId").As("oid"), C("ItemId")).
+					Where(C("ItemId").Gt(10)).AsSubquery("sub")
+				return NewSelector[Order](db).Select(t1.C("Id"), sub.C("ItemId")).
+					From(t1.Join(sub).On(t1.C("Id").Eq(sub.C("oid")))).
+					Where(t1.C("UserId").Eq(3))
+			}(),
+			wantQuery: &Query{
+				SQL: "SELECT `t1`.`id`,`sub`.`item_id` FROM (`order` AS `t1` JOIN " +
+					"(SELECT `order_id` AS `oid`,`item_id` FROM `order_detail` WHERE `item_id` > ?) AS `sub` " +
+					"ON `t1`.`id` = `sub`.`oid`) WHERE `t1`.`user_id` = ?;",
+				Args: []any{10, 3},
+			},
+		},
+		{
+			name: "reuse subquery",
+			s: func() QueryBuilder {
+				sub := NewSelector[Order](db).Where(C("UserId").Eq(12)).AsSubquery("sub")
+				return NewSelector[Order](db).From(sub).Where(Exists(sub))
+			}(),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM (SELECT * FROM `order` WHERE `user_id` = ?) AS `sub` " +
+					"WHERE EXISTS (SELECT * FROM `order` WHERE `user_id` = ?);",
+				Args: []any{12, 12},
+			},
+		},
+		{
+			name: "subquery unknown column",
+			s: func() QueryBuilder {
+				sub := NewSelector[OrderDetail](db).Select(C("OrderId")).AsSubquery("sub")
+				return NewSelector[Order](db).Select(sub.C("ItemId")).From(sub)
+			}(),
+			wantErr: errs.NewErrUnKnowField("ItemId"),
+		},
+		{
+			name: "subquery all columns",
+			s: func() QueryBuilder {
+				sub := NewSelector[OrderDetail](db).AsSubquery("sub")
+				return NewSelector[Order](db).Select(sub.C("ItemId")).From(sub)
+			}(),
+			wantQuery: &Query{
+				SQL: "SELECT `sub`.`item_id` FROM (SELECT * FROM `order_detail`) AS `sub`;",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := test.s.Build()
+			assert.Equal(t, test.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, test.wantQuery, q)
+		})
+	}
+}
+
+func TestSelector_Operators(t *testing.T) {
+	db := memoryDB(t)
+	tests := []struct {
+		name      string
+		s         QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "comparison",
+			s: NewSelector[TestModel](db).
+				Where(C("Id").NotEq(1), C("Age").LtEq(35), C("Age").GtEq(18)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE ((`id` != ?) AND (`age` <= ?)) AND (`age` >= ?);",
+				Args: []any{1, 35, 18},
+			},
+		},
+		{
+			name: "in",
+			s:    NewSelector[TestModel](db).Where(C("Id").In(1, 2, 3)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `id` IN (?,?,?);",
+				Args: []any{1, 2, 3},
+			},
+		},
+		{
+			name: "in slice",
+			s:    NewSelector[TestModel](db).Where(C("Id").In([]int64{1, 2})),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `id` IN (?,?);",
+				Args: []any{int64(1), int64(2)},
+			},
+		},
+		{
+			name: "in bytes",
+			s:    NewSelector[TestModel](db).Where(C("FirstName").In([]byte("xiao"))),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `first_name` IN (?);",
+				Args: []any{[]byte("xiao")},
+			},
+		},
+		{
+			name: "in empty",
+			s:    NewSelector[TestModel](db).Where(C("Id").In([]int{}), C("Age").Gt(18)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE (1 = 0) AND (`age` > ?);",
+				Args: []any{18},
+			},
+		},
+		{
+			name: "not in",
+			s:    NewSelector[TestModel](db).Where(C("Id").NotIn(1, 2)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `id` NOT IN (?,?);",
+				Args: []any{1, 2},
+			},
+		},
+		{
+			name: "in expression",
+			s:    NewSelector[TestModel](db).Where(C("Id").In(C("Age"), C("Age").Add(1), 3)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `id` IN (`age`,`age` + ?,?);",
+				Args: []any{1, 3},
+			},
+		},
+		{
+			name:    "in unknown column",
+			s:       NewSelector[TestModel](db).Where(C("Id").In(C("Invalid"))),
+			wantErr: errs.NewErrUnKnowField("Invalid"),
+		},
+		{
+			name: "not in empty",
+			s:    NewSelector[TestModel](db).Where(C("Id").NotIn()),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` WHERE 1 = 1;",
+			},
+		},
+		{
+			name: "between",
+			s:    NewSelector[TestModel](db).Where(C("Age").Between(18, 35), C("Id").Gt(1)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE (`age` BETWEEN ? AND ?) AND (`id` > ?);",
+				Args: []any{18, 35, 1},
+			},
+		},
+		{
+			name: "like",
+			s:    NewSelector[TestModel](db).Where(C("FirstName").Like("xiao%"), C("FirstName").NotLike("%long")),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE (`first_name` LIKE ?) AND (`first_name` NOT LIKE ?);",
+				Args: []any{"xiao%", "%long"},
+			},
+		},
+		{
+			name: "is null",
+			s:    NewSelector[TestModel](db).Where(C("LastName").IsNull(), C("Age").IsNotNull()),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` WHERE (`last_name` IS NULL) AND (`age` IS NOT NULL);",
+			},
+		},
+		{
+			name: "not is null",
+			s:    NewSelector[TestModel](db).Where(Not(C("LastName").IsNull())),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` WHERE NOT (`last_name` IS NULL);",
+			},
+		},
+		{
+			name:    "in unknown field",
+			s:       NewSelector[TestModel](db).Where(C("age").In(1)),
+			wantErr: errs.NewErrUnKnowField("age"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := test.s.Build()
+			assert.Equal(t, test.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, test.wantQuery, q)
+		})
+	}
+}
+
+func TestSelector_MathFunc(t *testing.T) {
+	db := memoryDB(t)
+	tests := []struct {
+		name      string
+		s         QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "math in where",
+			s:    NewSelector[TestModel](db).Where(C("Id").Multi(C("Age")).Gt(100)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `id` * `age` > ?;",
+				Args: []any{100},
+			},
+		},
+		{
+			name: "nested math",
+			s:    NewSelector[TestModel](db).Where(C("Age").Add(1).Multi(2).Eq(C("Id"))),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE (`age` + ?) * ? = `id`;",
+				Args: []any{1, 2},
+			},
+		},
+		{
+			name: "math in select",
+			s:    NewSelector[TestModel](db).Select(C("Id"), C("Age").Sub(1).As("age"), C("Age").Div(2)),
+			wantQuery: &Query{
+				SQL:  "SELECT `id`,`age` - ? AS `age`,`age` / ? FROM `test_model`;",
+				Args: []any{1, 2},
+			},
+		},
+		{
+			name: "func in select",
+			s:    NewSelector[TestModel](db).Select(Func("COALESCE", C("LastName"), "").As("last_name")),
+			wantQuery: &Query{
+				SQL:  "SELECT COALESCE(`last_name`,?) AS `last_name` FROM `test_model`;",
+				Args: []any{""},
+			},
+		},
+		{
+			name: "func in where",
+			s:    NewSelector[TestModel](db).Where(Func("LENGTH", C("FirstName")).GtEq(3)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE LENGTH(`first_name`) >= ?;",
+				Args: []any{3},
+			},
+		},
+		{
+			name:    "math unknown field",
+			s:       NewSelector[TestModel](db).Where(C("Id").Add(C("age")).Gt(1)),
+			wantErr: errs.NewErrUnKnowField("age"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := test.s.Build()
+			assert.Equal(t, test.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, test.wantQuery, q)
+		})
+	}
+}
+
 /*
 goos: windows
 goarch: amd64
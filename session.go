@@ -0,0 +1,14 @@
+package morm
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Session 代表一个会话，是 DB 和 Tx 的公共抽象
+// 所有的构造器都可以在 DB 或者 Tx 上执行
+type Session interface {
+	getCore() core
+	queryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	execContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
@@ -0,0 +1,85 @@
+package morm
+
+// Subquery 子查询，通过 Selector.AsSubquery 创建
+// 既可以作为表使用，也可以作为 IN、EXISTS、ANY、ALL 的右边
+type Subquery struct {
+	s       QueryBuilder
+	columns []Selectable
+	alias   string
+	// table 子查询 FROM 的表，用来解析子查询的列
+	table TableReference
+}
+
+func (Subquery) expr() {}
+
+func (s Subquery) tableAlias() string {
+	return s.alias
+}
+
+// C 引用子查询中的列，如果子查询指定了列，那么 name 只能是这些列的字段名或者别名
+func (s Subquery) C(name string) Column {
+	return Column{
+		name:  name,
+		table: s,
+	}
+}
+
+func (s Subquery) Join(right TableReference) *JoinBuilder {
+	return &JoinBuilder{
+		left:  s,
+		right: right,
+		typ:   "JOIN",
+	}
+}
+
+func (s Subquery) LeftJoin(right TableReference) *JoinBuilder {
+	return &JoinBuilder{
+		left:  s,
+		right: right,
+		typ:   "LEFT JOIN",
+	}
+}
+
+func (s Subquery) RightJoin(right TableReference) *JoinBuilder {
+	return &JoinBuilder{
+		left:  s,
+		right: right,
+		typ:   "RIGHT JOIN",
+	}
+}
+
+// SubqueryExpr 带有 ANY、ALL 修饰的子查询，例如 C("Age").Gt(Any(sub))
+type SubqueryExpr struct {
+	s    Subquery
+	pred string
+}
+
+func (SubqueryExpr) expr() {}
+
+func Any(sub Subquery) SubqueryExpr {
+	return SubqueryExpr{
+		s:    sub,
+		pred: "ANY",
+	}
+}
+
+func All(sub Subquery) SubqueryExpr {
+	return SubqueryExpr{
+		s:    sub,
+		pred: "ALL",
+	}
+}
+
+func Exists(sub Subquery) Predicate {
+	return Predicate{
+		op:    opEXISTS,
+		right: sub,
+	}
+}
+
+func NotExists(sub Subquery) Predicate {
+	return Predicate{
+		op:    opNOTEXISTS,
+		right: sub,
+	}
+}
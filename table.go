@@ -0,0 +1,127 @@
+package morm
+
+// TableReference 代表 FROM 和 JOIN 中可以使用的表
+// 目前有 Table、Join 和 RawExpr
+type TableReference interface {
+	tableAlias() string
+}
+
+// Table 普通的表，通过 TableOf 创建
+type Table struct {
+	entity any
+	alias  string
+}
+
+// TableOf entity 必须是结构体指针，例如 TableOf(&User{})
+func TableOf(entity any) Table {
+	return Table{
+		entity: entity,
+	}
+}
+
+func (t Table) tableAlias() string {
+	return t.alias
+}
+
+func (t Table) As(alias string) Table {
+	return Table{
+		entity: t.entity,
+		alias:  alias,
+	}
+}
+
+// C 指定列属于这张表，构造 SQL 的时候会用表的别名或者表名限定列
+func (t Table) C(name string) Column {
+	return Column{
+		name:  name,
+		table: t,
+	}
+}
+
+func (t Table) Join(right TableReference) *JoinBuilder {
+	return &JoinBuilder{
+		left:  t,
+		right: right,
+		typ:   "JOIN",
+	}
+}
+
+func (t Table) LeftJoin(right TableReference) *JoinBuilder {
+	return &JoinBuilder{
+		left:  t,
+		right: right,
+		typ:   "LEFT JOIN",
+	}
+}
+
+func (t Table) RightJoin(right TableReference) *JoinBuilder {
+	return &JoinBuilder{
+		left:  t,
+		right: right,
+		typ:   "RIGHT JOIN",
+	}
+}
+
+// Join 通过 JoinBuilder 的 On 或者 Using 创建
+// Join 本身也可以继续 Join 其它表
+type Join struct {
+	left  TableReference
+	right TableReference
+	typ   string
+	on    []Predicate
+	using []string
+}
+
+func (j Join) tableAlias() string {
+	return ""
+}
+
+func (j Join) Join(right TableReference) *JoinBuilder {
+	return &JoinBuilder{
+		left:  j,
+		right: right,
+		typ:   "JOIN",
+	}
+}
+
+func (j Join) LeftJoin(right TableReference) *JoinBuilder {
+	return &JoinBuilder{
+		left:  j,
+		right: right,
+		typ:   "LEFT JOIN",
+	}
+}
+
+func (j Join) RightJoin(right TableReference) *JoinBuilder {
+	return &JoinBuilder{
+		left:  j,
+		right: right,
+		typ:   "RIGHT JOIN",
+	}
+}
+
+type JoinBuilder struct {
+	left  TableReference
+	right TableReference
+	typ   string
+}
+
+// On 多个 Predicate 之间用 AND 连接
+func (j *JoinBuilder) On(ps ...Predicate) Join {
+	return Join{
+		left:  j.left,
+		right: j.right,
+		typ:   j.typ,
+		on:    ps,
+	}
+}
+
+// Using 传入的是字段名，两张表都必须有这些字段
+func (j *JoinBuilder) Using(cols ...string) Join {
+	return Join{
+		left:  j.left,
+		right: j.right,
+		typ:   j.typ,
+		using: cols,
+	}
+}
@@ -0,0 +1,44 @@
+package morm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+var _ Session = &Tx{}
+
+// Tx 事务，通过 DB.BeginTx 创建
+type Tx struct {
+	tx *sql.Tx
+	db *DB
+}
+
+func (t *Tx) getCore() core {
+	return t.db.core
+}
+
+func (t *Tx) queryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return t.tx.QueryContext(ctx, query, args...)
+}
+
+func (t *Tx) execContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return t.tx.ExecContext(ctx, query, args...)
+}
+
+func (t *Tx) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *Tx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// RollbackIfNotCommit 事务已经提交或回滚时不返回错误，适合在 defer 中使用
+func (t *Tx) RollbackIfNotCommit() error {
+	err := t.tx.Rollback()
+	if errors.Is(err, sql.ErrTxDone) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+package morm
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestTx_Commit(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `test_model`.*").
+		WillReturnResult(sqlmock.NewResult(12, 1))
+	mock.ExpectQuery("SELECT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name"}).AddRow(12, "xiao"))
+	mock.ExpectCommit()
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	require.NoError(t, err)
+
+	res := NewInserter[TestModel](tx).Values(&TestModel{Id: 12, FirstName: "xiao"}).
+		Exec(context.Background())
+	affected, err := res.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	tm, err := NewSelector[TestModel](tx).Where(C("Id").Eq(12)).Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &TestModel{Id: 12, FirstName: "xiao"}, tm)
+
+	require.NoError(t, tx.Commit())
+	assert.NoError(t, tx.RollbackIfNotCommit())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTx_Rollback(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM `test_model`.*").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	require.NoError(t, err)
+
+	res := NewDeleter[TestModel](tx).Where(C("Id").Eq(12)).Exec(context.Background())
+	_, err = res.RowsAffected()
+	require.NoError(t, err)
+
+	require.NoError(t, tx.Rollback())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
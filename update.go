@@ -3,41 +3,61 @@ package morm
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"github.com/soluble1/morm/internal/errs"
+	"github.com/soluble1/morm/internal/valuer"
 )
 
 type Updater[T any] struct {
 	builder
-	db *DB
+	sess Session
 
 	sets  []Predicate
 	where []Predicate
+
+	allowFullTable bool
+	returning      returning
 }
 
 func (u *Updater[T]) Exec(ctx context.Context) sql.Result {
-	q, err := u.Build()
-	if err != nil {
-		return Result{
-			err: err,
-		}
-	}
-	res, err := u.db.db.ExecContext(ctx, q.SQL, q.Args...)
-	return Result{
-		res: res,
-		err: err,
+	qc := newQueryContext[T](u.core, "UPDATE", u)
+	if u.returning.set {
+		return execReturning[T](ctx, u.sess, qc)
 	}
+	return exec(ctx, u.sess, qc)
 }
 
-func NewUpdater[T any](db *DB) *Updater[T] {
+// Returning 不传入列表示返回所有的列，通过 GetMulti 拿到结果，MySQL 不支持
+func (u *Updater[T]) Returning(cols ...string) *Updater[T] {
+	u.returning = returning{set: true, cols: cols}
+	return u
+}
+
+// GetMulti 执行语句并返回 RETURNING 的结果，没有调用 Returning 的时候返回所有的列
+func (u *Updater[T]) GetMulti(ctx context.Context) ([]*T, error) {
+	u.returning.set = true
+	return returningMulti[T](ctx, u.sess, newQueryContext[T](u.core, "UPDATE", u))
+}
+
+// AllowFullTable 没有 WHERE 也可以执行，用于绕过 safety 之类的 Middleware
+func (u *Updater[T]) AllowFullTable() *Updater[T] {
+	u.allowFullTable = true
+	return u
+}
+
+func (u *Updater[T]) IsFullTable() bool {
+	return !u.allowFullTable && len(u.where) == 0
+}
+
+func NewUpdater[T any](sess Session) *Updater[T] {
 	return &Updater[T]{
-		db: db,
+		sess: sess,
 		builder: builder{
-			dialect: db.dialect,
+			core: sess.getCore(),
 		},
 	}
 }
 
+// Set 只支持 Eq，例如 Set(C("Age").Eq(18), C("Stock").Eq(C("Stock").Sub(1)))
 func (u *Updater[T]) Set(sets ...Predicate) *Updater[T] {
 	u.sets = sets
 	return u
@@ -51,13 +71,13 @@ func (u *Updater[T]) Where(ps ...Predicate) *Updater[T] {
 func (u *Updater[T]) Build() (*Query, error) {
 	t := new(T)
 	var err error
-	u.model, err = u.db.r.Get(t)
+	u.model, err = u.r.Get(t)
 	if err != nil {
 		return nil, err
 	}
 
 	u.sb.WriteString("UPDATE ")
-	u.quote(u.model.TableName)
+	u.quoteTable(u.model)
 	u.sb.WriteByte(' ')
 	u.sb.WriteString("SET ")
 
@@ -78,10 +98,6 @@ func (u *Updater[T]) Build() (*Query, error) {
 		if !ok {
 			return nil, errs.ErrNonSupportOperator
 		}
-		r, ok := p.right.(Value)
-		if !ok {
-			return nil, errs.ErrNonSupportOperator
-		}
 		lname, ok := u.model.FieldMap[l.name]
 		if !ok {
 			return nil, errs.NewErrUnKnowField(l.name)
@@ -90,23 +106,33 @@ func (u *Updater[T]) Build() (*Query, error) {
 		u.sb.WriteByte(' ')
 		u.sb.WriteString(p.op.String())
 		u.sb.WriteByte(' ')
-		u.sb.WriteByte('?')
-		u.args = append(u.args, r.val)
+		// JSON 字段的值需要先编码
+		if val, ok := p.right.(Value); ok && lname.JSON {
+			arg, err := valuer.EncodeJSON(lname, val.val)
+			if err != nil {
+				return nil, err
+			}
+			u.addArg(arg)
+			continue
+		}
+		// 右边可以是值，也可以是 Column、MathExpr、FuncExpr 之类的表达式
+		if err = u.buildExpression(p.right); err != nil {
+			return nil, err
+		}
 	}
 
 	if len(u.where) > 0 {
 		u.sb.WriteByte(' ')
 		u.sb.WriteString("WHERE ")
-		t := u.where[0]
-		for i := 1; i < len(u.where); i++ {
-			t = t.And(u.where[i])
-		}
-		err = u.buildExpression(t)
-		if err != nil {
+		if err = u.buildPredicates(u.where); err != nil {
 			return nil, err
 		}
 	}
 
+	if err = u.buildReturning(u.returning); err != nil {
+		return nil, err
+	}
+
 	u.sb.WriteByte(';')
 
 	return &Query{
@@ -114,61 +140,3 @@ func (u *Updater[T]) Build() (*Query, error) {
 		Args: u.args,
 	}, nil
 }
-
-func (u *Updater[T]) buildExpression(expression Expression) error {
-	switch expr := expression.(type) {
-	case nil:
-		return nil
-	case Value:
-		u.sb.WriteByte('?')
-		if u.args == nil {
-			u.args = make([]any, 0, 8)
-		}
-		u.args = append(u.args, expr.val)
-	case Column:
-		u.sb.WriteByte('`')
-		fd, ok := u.model.FieldMap[expr.name]
-		if !ok {
-			return errs.NewErrUnKnowField(expr.name)
-		}
-		u.sb.WriteString(fd.ColName)
-		u.sb.WriteByte('`')
-	case Predicate:
-		P, ok := expr.left.(Predicate)
-		if ok && P.op != opNOT {
-			u.sb.WriteByte('(')
-		}
-		if err := u.buildExpression(expr.left); err != nil {
-			return err
-		}
-		if ok && P.op != opNOT {
-			u.sb.WriteByte(')')
-		}
-
-		if expr.op != opNOT && expr.op != "" {
-			u.sb.WriteByte(' ')
-
-		}
-		u.sb.WriteString(expr.op.String())
-		if expr.op != "" {
-			u.sb.WriteByte(' ')
-		}
-
-		_, ok = expr.right.(Predicate)
-		if ok {
-			u.sb.WriteByte('(')
-		}
-		if err := u.buildExpression(expr.right); err != nil {
-			return err
-		}
-		if ok {
-			u.sb.WriteByte(')')
-		}
-	case RawExpr:
-		u.sb.WriteString(expr.raw)
-		u.args = append(u.args, expr.args...)
-	default:
-		return errors.New("orm: 不支持的表达式")
-	}
-	return nil
-}
@@ -1,9 +1,11 @@
 package morm
 
 import (
+	"encoding/json"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/soluble1/morm/internal/errs"
 	"github.com/stretchr/testify/assert"
+	"reflect"
 	"testing"
 )
 
@@ -63,6 +65,66 @@ func TestUpdater_Build(t *testing.T) {
 				Args: []any{24, 19, 10, 18},
 			},
 		},
+		{
+			name: "update math",
+
+			u: NewUpdater[TestModel](db).
+				Set(C("Age").Eq(C("Age").Sub(1))).
+				Where(C("Id").Eq(12)),
+
+			wantQuery: &Query{
+				SQL:  "UPDATE `test_model` SET `age` = `age` - ? WHERE `id` = ?;",
+				Args: []any{1, 12},
+			},
+		},
+		{
+			name: "update column and func",
+
+			u: NewUpdater[TestModel](db).
+				Set(C("Age").Eq(C("Id")), C("FirstName").Eq(Func("COALESCE", C("FirstName"), "xiao"))),
+
+			wantQuery: &Query{
+				SQL:  "UPDATE `test_model` SET `age` = `id`, `first_name` = COALESCE(`first_name`,?);",
+				Args: []any{"xiao"},
+			},
+		},
+		{
+			name: "update raw",
+
+			u: NewUpdater[TestModel](db).Set(C("Age").Eq(Raw("`age` + ?", 1))),
+
+			wantQuery: &Query{
+				SQL:  "UPDATE `test_model` SET `age` = `age` + ?;",
+				Args: []any{1},
+			},
+		},
+		{
+			name: "Err left not column",
+
+			u: NewUpdater[TestModel](db).Set(C("Age").Add(1).Eq(19)),
+
+			wantErr: errs.ErrNonSupportOperator,
+		},
+		{
+			name: "update json",
+
+			u: NewUpdater[JSONModel](db).Set(C("Settings").Eq(map[string]string{"theme": "dark"}),
+				C("Tags").Eq([]string(nil)), C("Profile").Eq(JsonColumn[User]{Val: User{Name: "xiao"}, Valid: true})).
+				Where(C("Id").Eq(1)),
+
+			wantQuery: &Query{
+				SQL: "UPDATE `json_model` SET `settings` = ?, `tags` = ?, `profile` = ? WHERE `id` = ?;",
+				Args: []any{`{"theme":"dark"}`, nil,
+					JsonColumn[User]{Val: User{Name: "xiao"}, Valid: true}, 1},
+			},
+		},
+		{
+			name: "Err update json",
+
+			u: NewUpdater[JSONModel](db).Set(C("Settings").Eq(func() {})),
+
+			wantErr: errs.NewErrInvalidJSON("Settings", &json.UnsupportedTypeError{Type: reflect.TypeOf(func() {})}),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {